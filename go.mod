@@ -9,5 +9,9 @@ require pkg/checkhttp v0.0.0-00010101000000-000000000000
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/sni/go-flags v0.0.0-20240724130408-1ec865bcf4f3 // indirect
-	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/crypto v0.25.0 // indirect
+	golang.org/x/net v0.27.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	software.sslmate.com/src/go-pkcs12 v0.7.3 // indirect
 )
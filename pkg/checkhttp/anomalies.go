@@ -0,0 +1,197 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+const maxRecordedHeaderBytes = 256 * 1024
+
+// headerRecorder keeps the raw bytes of the response head as read from the
+// wire, before net/http normalizes or rejects them.
+type headerRecorder struct {
+	mu   sync.Mutex
+	buf  []byte
+	done bool
+}
+
+func (r *headerRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = nil
+	r.done = false
+}
+
+func (r *headerRecorder) record(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return
+	}
+	r.buf = append(r.buf, p...)
+	if len(r.buf) >= maxRecordedHeaderBytes {
+		r.buf = r.buf[:maxRecordedHeaderBytes]
+		r.done = true
+		return
+	}
+	// skip interim (1xx) responses and stop after the final header block
+	for _, block := range splitHeaderBlocks(r.buf) {
+		if !isInterimResponse(block) {
+			r.done = true
+		}
+	}
+}
+
+// Head returns the recorded header block of the final response.
+func (r *headerRecorder) Head() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blocks := splitHeaderBlocks(r.buf)
+	for _, block := range blocks {
+		if !isInterimResponse(block) {
+			return block
+		}
+	}
+	if len(blocks) > 0 {
+		return blocks[len(blocks)-1]
+	}
+	return r.buf
+}
+
+// splitHeaderBlocks returns all complete header blocks found in buf.
+func splitHeaderBlocks(buf []byte) [][]byte {
+	var blocks [][]byte
+	for len(buf) > 0 {
+		end, sepLen := bytes.Index(buf, []byte("\r\n\r\n")), 4
+		if lf := bytes.Index(buf, []byte("\n\n")); lf >= 0 && (end < 0 || lf < end) {
+			end, sepLen = lf, 2
+		}
+		if end < 0 {
+			break
+		}
+		blocks = append(blocks, buf[:end])
+		buf = buf[end+sepLen:]
+	}
+	return blocks
+}
+
+func isInterimResponse(block []byte) bool {
+	fields := strings.Fields(string(firstLine(block)))
+	return len(fields) > 1 && len(fields[1]) == 3 && fields[1][0] == '1'
+}
+
+func firstLine(block []byte) []byte {
+	if i := bytes.IndexByte(block, '\n'); i >= 0 {
+		return bytes.TrimRight(block[:i], "\r")
+	}
+	return block
+}
+
+type recordingConn struct {
+	net.Conn
+	rec *headerRecorder
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.rec.record(p[:n])
+	}
+	return n, err
+}
+
+// recordingDialers wraps the plain and TLS dialers so every response head
+// is recorded. TLS is handled here, which restricts the connection to
+// HTTP/1.1 where header anomalies matter.
+func recordingDialers(rec *headerRecorder, dial func(ctx context.Context, network, addr string) (net.Conn, error), tlsConfig *tls.Config, serverName func(addr string) string) (func(ctx context.Context, network, addr string) (net.Conn, error), func(ctx context.Context, network, addr string) (net.Conn, error)) {
+	plain := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &recordingConn{conn, rec}, nil
+	}
+	secure := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := tlsConfig.Clone()
+		cfg.NextProtos = []string{"http/1.1"}
+		cfg.ServerName = serverName(addr)
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return &recordingConn{tlsConn, rec}, nil
+	}
+	return plain, secure
+}
+
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c > 0x7e || c <= 0x20 || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// headerAnomalies lists smuggling-prone irregularities in a raw response head.
+func headerAnomalies(head []byte) []string {
+	var anomalies []string
+	lines := strings.Split(strings.ReplaceAll(string(head), "\r\n", "\n"), "\n")
+	hasTE := false
+	contentLengths := map[string]bool{}
+	for i, line := range lines {
+		if i == 0 || line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			anomalies = append(anomalies, fmt.Sprintf("obs-fold continuation line %q", strings.TrimSpace(line)))
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			anomalies = append(anomalies, fmt.Sprintf("malformed header line %q", line))
+			continue
+		}
+		if !isHeaderToken(name) {
+			anomalies = append(anomalies, fmt.Sprintf("invalid header name %q", name))
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "transfer-encoding":
+			hasTE = true
+		case "content-length":
+			contentLengths[strings.TrimSpace(value)] = true
+		}
+	}
+	if hasTE && len(contentLengths) > 0 {
+		anomalies = append(anomalies, "both Transfer-Encoding and Content-Length present")
+	}
+	if len(contentLengths) > 1 {
+		anomalies = append(anomalies, "conflicting Content-Length values")
+	}
+	return anomalies
+}
+
+func checkHeaderAnomalies(opts commandOpts) *reqError {
+	anomalies := headerAnomalies(opts.headerRecorder.Head())
+	if len(anomalies) == 0 {
+		return nil
+	}
+	return &reqError{
+		fmt.Sprintf("HTTP WARNING - Header anomalies detected from host on port %d: %s", opts.Port, strings.Join(anomalies, ", ")),
+		WARNING,
+	}
+}
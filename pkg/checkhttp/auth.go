@@ -0,0 +1,117 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+func (c authChallenge) String() string {
+	keys := make([]string, 0, len(c.params))
+	for k := range c.params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := c.scheme
+	for _, k := range keys {
+		s += fmt.Sprintf(" %s=%q", k, c.params[k])
+	}
+	return s
+}
+
+// parseAuthParam splits a `name=value` auth parameter, unquoting the value.
+func parseAuthParam(s string) (string, string, bool) {
+	k, v, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok || k == "" || strings.ContainsAny(k, " \t") {
+		return "", "", false
+	}
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) {
+		v = strings.ReplaceAll(v[1:len(v)-1], `\"`, `"`)
+	}
+	return strings.ToLower(k), v, true
+}
+
+// parseAuthChallenges parses all WWW-Authenticate challenges of a response,
+// e.g. `Bearer realm="api", error="invalid_token", Basic realm="web"`.
+func parseAuthChallenges(h http.Header) []authChallenge {
+	var challenges []authChallenge
+	for _, v := range h.Values("WWW-Authenticate") {
+		for _, item := range splitQuoted(v, ',') {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if k, val, ok := parseAuthParam(item); ok && len(challenges) > 0 {
+				challenges[len(challenges)-1].params[k] = val
+				continue
+			}
+			scheme, rest, _ := strings.Cut(item, " ")
+			c := authChallenge{scheme: scheme, params: map[string]string{}}
+			if k, val, ok := parseAuthParam(rest); ok {
+				c.params[k] = val
+			}
+			challenges = append(challenges, c)
+		}
+	}
+	return challenges
+}
+
+// parseExpectAuthScheme parses the --expect-auth-scheme value
+// `Scheme[,name=value...]`.
+func parseExpectAuthScheme(s string) (authChallenge, error) {
+	parts := strings.Split(s, ",")
+	c := authChallenge{scheme: strings.TrimSpace(parts[0]), params: map[string]string{}}
+	if c.scheme == "" || strings.ContainsAny(c.scheme, " =") {
+		return c, fmt.Errorf("invalid auth scheme %q", c.scheme)
+	}
+	for _, p := range parts[1:] {
+		k, v, ok := parseAuthParam(p)
+		if !ok {
+			return c, fmt.Errorf("invalid auth parameter %q, expected name=value", p)
+		}
+		c.params[k] = v
+	}
+	return c, nil
+}
+
+// checkAuthChallenge verifies the response is a 401 carrying the expected
+// WWW-Authenticate challenge.
+func checkAuthChallenge(opts commandOpts, res *http.Response, statusLine string) (string, *reqError) {
+	if res.StatusCode != http.StatusUnauthorized {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Expected 401 authentication challenge from host on port %d: %s", opts.Port, statusLine),
+			CRITICAL,
+		}
+	}
+	expect := opts.expectAuthScheme
+	challenges := parseAuthChallenges(res.Header)
+	for _, c := range challenges {
+		if !strings.EqualFold(c.scheme, expect.scheme) {
+			continue
+		}
+		for k, v := range expect.params {
+			if c.params[k] != v {
+				return "", &reqError{
+					fmt.Sprintf("HTTP CRITICAL - WWW-Authenticate %s has %s=%q instead of %q from host on port %d", c.scheme, k, c.params[k], v, opts.Port),
+					CRITICAL,
+				}
+			}
+		}
+		return fmt.Sprintf("%s, authentication challenge %s matched", statusLine, c), nil
+	}
+	found := make([]string, 0, len(challenges))
+	for _, c := range challenges {
+		found = append(found, c.scheme)
+	}
+	return "", &reqError{
+		fmt.Sprintf("HTTP CRITICAL - No WWW-Authenticate %s challenge (got: %s) from host on port %d", expect.scheme, strings.Join(found, ", "), opts.Port),
+		CRITICAL,
+	}
+}
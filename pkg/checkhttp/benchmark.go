@@ -0,0 +1,131 @@
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// benchmarkBuckets are the upper bounds of the latency histogram.
+var benchmarkBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second,
+}
+
+// percentile returns the p-th percentile of sorted durations (nearest rank).
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// latencyHistogram renders the durations as text histogram lines.
+func latencyHistogram(durations []time.Duration) []string {
+	counts := make([]int, len(benchmarkBuckets)+1)
+	for _, d := range durations {
+		i := sort.Search(len(benchmarkBuckets), func(i int) bool { return d <= benchmarkBuckets[i] })
+		counts[i]++
+	}
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	var lines []string
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		label := "> " + benchmarkBuckets[len(benchmarkBuckets)-1].String()
+		if i < len(benchmarkBuckets) {
+			label = "<= " + benchmarkBuckets[i].String()
+		}
+		lines = append(lines, fmt.Sprintf("%8s %5d %s", label, c, strings.Repeat("#", (c*40+maxCount-1)/maxCount)))
+	}
+	return lines
+}
+
+// runBenchmark performs --benchmark requests, optionally limited to --rate
+// requests per second, and reports latency percentiles and a histogram.
+func runBenchmark(ctx context.Context, client *http.Client, opts commandOpts) (int, string) {
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+	var durations []time.Duration
+	var lastErr *reqError
+	failed := 0
+	start := time.Now()
+	for i := 0; i < opts.Benchmark && ctx.Err() == nil; i++ {
+		if interval > 0 && i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(start.Add(time.Duration(i) * interval))):
+			}
+		}
+		okMsg, reqErr := request(ctx, client, opts)
+		if reqErr != nil {
+			if opts.Verbose {
+				log.Printf("request[%d]: %s", i+1, reqErr.Error())
+			}
+			failed++
+			lastErr = reqErr
+			continue
+		}
+		if opts.Verbose {
+			log.Printf("request[%d]: %s", i+1, okMsg)
+		}
+		durations = append(durations, opts.result.Duration)
+	}
+	total := failed + len(durations)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	p50, p95, p99 := percentile(durations, 50), percentile(durations, 95), percentile(durations, 99)
+
+	state := OK
+	switch {
+	case len(durations) == 0, opts.BenchmarkCritical > 0 && p95 > opts.BenchmarkCritical:
+		state = CRITICAL
+	case failed > 0, opts.BenchmarkWarning > 0 && p95 > opts.BenchmarkWarning:
+		state = WARNING
+	}
+
+	msg := fmt.Sprintf("HTTP %s - %d requests, %d failed, p50 %.3f p95 %.3f p99 %.3f second response time",
+		stateNames[state], total, failed, p50.Seconds(), p95.Seconds(), p99.Seconds())
+	if lastErr != nil {
+		lastMsg, _, _ := strings.Cut(lastErr.Error(), "\n")
+		msg += fmt.Sprintf(" (last error: %s)", lastMsg)
+	}
+	perfdata := []string{
+		fmt.Sprintf("requests=%d;;;0;", total),
+		fmt.Sprintf("failed=%d;;;0;%d", failed, total),
+		fmt.Sprintf("p50=%fs;;;0;", p50.Seconds()),
+		fmt.Sprintf("p95=%fs;%s;%s;0;", p95.Seconds(), formatDurationThreshold(opts.BenchmarkWarning), formatDurationThreshold(opts.BenchmarkCritical)),
+		fmt.Sprintf("p99=%fs;;;0;", p99.Seconds()),
+	}
+	if len(durations) > 0 {
+		perfdata = append(perfdata,
+			fmt.Sprintf("min=%fs;;;0;", durations[0].Seconds()),
+			fmt.Sprintf("max=%fs;;;0;", durations[len(durations)-1].Seconds()),
+		)
+	}
+	msg += " | " + strings.Join(perfdata, " ")
+	if hist := latencyHistogram(durations); len(hist) > 0 {
+		msg += "\n" + strings.Join(hist, "\n")
+	}
+	return state, msg
+}
@@ -0,0 +1,109 @@
+package checkhttp
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// streamMatcher searches a fixed pattern in a stream of writes using the
+// Knuth-Morris-Pratt algorithm. Matches spanning write boundaries are found
+// and memory usage only depends on the pattern length, not on the body size.
+type streamMatcher struct {
+	pattern []byte
+	failure []int
+	state   int
+	matched bool
+}
+
+func newStreamMatcher(pattern []byte) *streamMatcher {
+	failure := make([]int, len(pattern))
+	k := 0
+	for i := 1; i < len(pattern); i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = failure[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		failure[i] = k
+	}
+	return &streamMatcher{
+		pattern: pattern,
+		failure: failure,
+	}
+}
+
+func (m *streamMatcher) Write(p []byte) (int, error) {
+	if m.matched || len(m.pattern) == 0 {
+		m.matched = true
+		return len(p), nil
+	}
+	for _, c := range p {
+		for m.state > 0 && c != m.pattern[m.state] {
+			m.state = m.failure[m.state-1]
+		}
+		if c == m.pattern[m.state] {
+			m.state++
+		}
+		if m.state == len(m.pattern) {
+			m.matched = true
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// Matched returns true once the pattern has been seen in the stream.
+func (m *streamMatcher) Matched() bool {
+	return m.matched
+}
+
+// lineCounter counts lines of the written body. A last line without
+// trailing newline is counted as well.
+type lineCounter struct {
+	lines   int
+	pending bool
+}
+
+func (l *lineCounter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if c == '\n' {
+			l.lines++
+			l.pending = false
+		} else {
+			l.pending = true
+		}
+	}
+	return len(p), nil
+}
+
+func (l *lineCounter) Lines() int {
+	if l.pending {
+		return l.lines + 1
+	}
+	return l.lines
+}
+
+// decompressBody returns a reader decoding the Content-Encoding of res.
+// The compressed bytes are written to raw as they are read.
+func decompressBody(res *http.Response, raw io.Writer) (io.Reader, error) {
+	body := io.TeeReader(res.Body, raw)
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		// deflate is zlib wrapped but some servers send a raw stream
+		br := bufio.NewReader(body)
+		header, err := br.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,41 @@
+package checkhttp
+
+import "testing"
+
+func TestStreamMatcher(t *testing.T) {
+	tests := []struct {
+		body    string
+		pattern string
+		want    bool
+	}{
+		{body: "hello world", pattern: "world", want: true},
+		{body: "hello world", pattern: "hello", want: true},
+		{body: "hello world", pattern: "worlds", want: false},
+		{body: "aaab", pattern: "aab", want: true},
+		{body: "aabaabaaab", pattern: "aabaaab", want: true},
+		{body: "abababc", pattern: "ababc", want: true},
+		{body: "ababab", pattern: "ababc", want: false},
+		{body: "abc", pattern: "", want: true},
+		{body: "", pattern: "a", want: false},
+		{body: "ab", pattern: "abc", want: false},
+	}
+	for _, tt := range tests {
+		// feed the body in two chunks, split at every offset
+		for split := 0; split <= len(tt.body); split++ {
+			m := newStreamMatcher([]byte(tt.pattern))
+			m.Write([]byte(tt.body[:split]))
+			m.Write([]byte(tt.body[split:]))
+			if got := m.Matched(); got != tt.want {
+				t.Errorf("match %q in %q split at %d = %v, want %v", tt.pattern, tt.body, split, got, tt.want)
+			}
+		}
+		// feed the body byte by byte, i.e. chunks shorter than the pattern
+		m := newStreamMatcher([]byte(tt.pattern))
+		for i := 0; i < len(tt.body); i++ {
+			m.Write([]byte{tt.body[i]})
+		}
+		if got := m.Matched(); got != tt.want {
+			t.Errorf("match %q in %q byte by byte = %v, want %v", tt.pattern, tt.body, got, tt.want)
+		}
+	}
+}
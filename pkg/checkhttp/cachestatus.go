@@ -0,0 +1,138 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// cdnCacheStatus maps the values of CF-Cache-Status and similar headers.
+var cdnCacheStatus = map[string]string{
+	"HIT":         "HIT",
+	"STALE":       "HIT",
+	"UPDATING":    "HIT",
+	"REVALIDATED": "HIT",
+	"MISS":        "MISS",
+	"EXPIRED":     "MISS",
+	"BYPASS":      "BYPASS",
+	"DYNAMIC":     "BYPASS",
+	"PASS":        "BYPASS",
+}
+
+// parseCacheStatusHeader returns the status of the cache closest to the
+// client from a RFC 9211 Cache-Status header, e.g. `Origin; fwd=miss, CDN; hit`.
+func parseCacheStatusHeader(value string) string {
+	entries := splitQuoted(value, ',')
+	params := splitQuoted(entries[len(entries)-1], ';')
+	for _, p := range params[1:] {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		switch strings.ToLower(kv[0]) {
+		case "hit":
+			return "HIT"
+		case "fwd":
+			if len(kv) == 2 && (kv[1] == "bypass" || kv[1] == "request") {
+				return "BYPASS"
+			}
+			return "MISS"
+		}
+	}
+	return ""
+}
+
+// parseXCache returns the status of the last cache from a X-Cache header like
+// `HIT`, `Hit from cloudfront` or `MISS, HIT`.
+func parseXCache(value string) string {
+	entries := strings.Split(value, ",")
+	last := strings.ToUpper(entries[len(entries)-1])
+	for _, status := range []string{"BYPASS", "PASS", "MISS", "HIT"} {
+		if strings.Contains(last, status) {
+			return cdnCacheStatus[status]
+		}
+	}
+	return ""
+}
+
+// cacheStatus determines whether the response was served from a cache and
+// returns the status together with the header it was derived from.
+func cacheStatus(h http.Header) (status, source string) {
+	if v := h.Get("Cache-Status"); v != "" {
+		if status = parseCacheStatusHeader(v); status != "" {
+			return status, "Cache-Status"
+		}
+	}
+	if v := h.Get("CF-Cache-Status"); v != "" {
+		if status = cdnCacheStatus[strings.ToUpper(strings.TrimSpace(v))]; status != "" {
+			return status, "CF-Cache-Status"
+		}
+	}
+	for _, name := range []string{"X-Cache", "X-Cache-Status", "X-Proxy-Cache"} {
+		if v := h.Get(name); v != "" {
+			if status = parseXCache(v); status != "" {
+				return status, name
+			}
+		}
+	}
+	if age, err := strconv.ParseInt(strings.TrimSpace(h.Get("Age")), 10, 64); err == nil && age > 0 {
+		return "HIT", "Age"
+	}
+	return "", ""
+}
+
+// checkCacheStatus compares the observed cache status with --expect-cache-status.
+func checkCacheStatus(opts commandOpts, h http.Header) (string, *reqError) {
+	status, source := cacheStatus(h)
+	if status == "" {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Cache status could not be determined (expected %s) from host on port %d", opts.ExpectCacheStatus, opts.Port),
+			CRITICAL,
+		}
+	}
+	if status != opts.ExpectCacheStatus {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Cache status is %s (expected %s, from %s) from host on port %d", status, opts.ExpectCacheStatus, source, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("cache %s (%s)", status, source), nil
+}
+
+// cdnEdge returns the edge location which served the response and the
+// header it was derived from.
+func cdnEdge(h http.Header) (edge, source string) {
+	// Cloudflare: cf-ray: 8a1b2c3d4e5f6a7b-FRA
+	if v := h.Get("CF-Ray"); v != "" {
+		if i := strings.LastIndexByte(v, '-'); i >= 0 && i < len(v)-1 {
+			return strings.TrimSpace(v[i+1:]), "CF-Ray"
+		}
+	}
+	// CloudFront: x-amz-cf-pop: FRA56-P1
+	if v := strings.TrimSpace(h.Get("X-Amz-Cf-Pop")); v != "" {
+		return v, "X-Amz-Cf-Pop"
+	}
+	// Fastly: x-served-by: cache-fra-eddf8230066-FRA, cache-ams21042-AMS
+	if v := h.Get("X-Served-By"); v != "" {
+		entries := strings.Split(v, ",")
+		return strings.TrimSpace(entries[len(entries)-1]), "X-Served-By"
+	}
+	// Via: 1.1 varnish, 1.1 abc.cloudfront.net (CloudFront)
+	if v := h.Get("Via"); v != "" {
+		entries := splitQuoted(v, ',')
+		fields := strings.Fields(entries[len(entries)-1])
+		if len(fields) > 1 {
+			return fields[1], "Via"
+		}
+	}
+	return "", ""
+}
+
+// edgeLabel converts an edge name into a perfdata label.
+func edgeLabel(edge string) string {
+	return "cdn_edge_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, edge)
+}
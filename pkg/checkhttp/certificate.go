@@ -0,0 +1,299 @@
+package checkhttp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseCertificateDays parses the warn[:crit] days of -C, a comma is
+// accepted as separator as well like in the classic check_http.
+func parseCertificateDays(s string) (int, int, error) {
+	warnStr, critStr, ok := strings.Cut(strings.ReplaceAll(s, ",", ":"), ":")
+	warn, err := strconv.Atoi(warnStr)
+	if err != nil || warn < 0 {
+		return 0, 0, fmt.Errorf("invalid warning days %q", warnStr)
+	}
+	if !ok {
+		return warn, 0, nil
+	}
+	crit, err := strconv.Atoi(critStr)
+	if err != nil || crit < 0 {
+		return 0, 0, fmt.Errorf("invalid critical days %q", critStr)
+	}
+	if crit > warn {
+		return 0, 0, fmt.Errorf("critical days %d must not be larger than warning days %d", crit, warn)
+	}
+	return warn, crit, nil
+}
+
+// certificateDays returns the full days until the certificate expires.
+func certificateDays(cert *x509.Certificate) int {
+	return int(time.Until(cert.NotAfter).Hours() / 24)
+}
+
+// certificateDaysPerfdata returns the cert_days_remaining perfdata, with the
+// -C thresholds as lower bounds when set.
+func certificateDaysPerfdata(opts commandOpts, cert *x509.Certificate) string {
+	warn, crit := "", ""
+	if opts.Certificate != "" {
+		warn = fmt.Sprintf("%d:", opts.certWarningDays)
+		if opts.certCriticalDays > 0 {
+			crit = fmt.Sprintf("%d:", opts.certCriticalDays)
+		}
+	}
+	return fmt.Sprintf("cert_days_remaining=%d;%s;%s;;", certificateDays(cert), warn, crit)
+}
+
+// checkCertificateExpiry checks the days until the server certificate
+// expires against the -C thresholds.
+func checkCertificateExpiry(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - No server certificate received from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	cert := state.PeerCertificates[0]
+	remaining := time.Until(cert.NotAfter)
+	days := certificateDays(cert)
+	expires := cert.NotAfter.UTC().Format("2006-01-02 15:04 MST")
+	switch {
+	case remaining <= 0:
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Certificate '%s' expired on %s from host on port %d", cert.Subject.CommonName, expires, opts.Port),
+			CRITICAL,
+		}
+	case days < opts.certCriticalDays:
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Certificate '%s' expires in %d day(s) (%s) from host on port %d", cert.Subject.CommonName, days, expires, opts.Port),
+			CRITICAL,
+		}
+	case days < opts.certWarningDays:
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Certificate '%s' expires in %d day(s) (%s) from host on port %d", cert.Subject.CommonName, days, expires, opts.Port),
+			WARNING,
+		}
+	}
+	return fmt.Sprintf("certificate '%s' expires in %d days (%s)", cert.Subject.CommonName, days, expires), nil
+}
+
+// publicKeyPin returns the base64 SHA-256 pin of the certificate public key.
+func publicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// checkPublicKeyPins verifies at least one presented certificate matches
+// one of the --pin-sha256 pins.
+func checkPublicKeyPins(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No server certificate to match pins from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	for _, cert := range state.PeerCertificates {
+		pin := publicKeyPin(cert)
+		for _, p := range opts.PinSHA256 {
+			if strings.TrimPrefix(p, "sha256//") == pin {
+				return fmt.Sprintf("public key pin of '%s' matched", cert.Subject.CommonName), nil
+			}
+		}
+	}
+	return "", &reqError{
+		fmt.Sprintf("HTTP CRITICAL - No certificate matched the pinned public keys, server key is sha256//%s from host on port %d", publicKeyPin(state.PeerCertificates[0]), opts.Port),
+		CRITICAL,
+	}
+}
+
+// certificateSANs lists the DNS names, IP addresses, emails and URIs of the
+// certificate.
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// publicKeyDescription returns the key algorithm and size, e.g. RSA 2048.
+func publicKeyDescription(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// certificateChainLines describes each presented certificate for the long
+// output.
+func certificateChainLines(state *tls.ConnectionState) []string {
+	const dateFormat = "2006-01-02 15:04 MST"
+	var lines []string
+	for i, cert := range state.PeerCertificates {
+		lines = append(lines,
+			fmt.Sprintf("certificate %d: subject %s", i, cert.Subject),
+			fmt.Sprintf("  issuer: %s", cert.Issuer),
+			fmt.Sprintf("  valid: %s - %s", cert.NotBefore.UTC().Format(dateFormat), cert.NotAfter.UTC().Format(dateFormat)),
+			fmt.Sprintf("  key: %s, signature: %s", publicKeyDescription(cert), cert.SignatureAlgorithm),
+		)
+		if sans := certificateSANs(cert); len(sans) > 0 {
+			lines = append(lines, fmt.Sprintf("  SANs: %s", strings.Join(sans, ", ")))
+		}
+	}
+	return lines
+}
+
+// maxCertificateValidity is the CA/Browser forum limit for the validity
+// period of server certificates.
+const maxCertificateValidity = 398 * 24 * time.Hour
+
+// certificateWeaknesses returns the hygiene problems of the presented
+// certificate chain.
+func certificateWeaknesses(state *tls.ConnectionState) []string {
+	var problems []string
+	for i, cert := range state.PeerCertificates {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < 2048 {
+			problems = append(problems, fmt.Sprintf("'%s' uses a %d bit RSA key", cert.Subject.CommonName, key.N.BitLen()))
+		}
+		// self-signed roots are trusted by their key, not their signature
+		selfSigned := bytes.Equal(cert.RawIssuer, cert.RawSubject)
+		switch cert.SignatureAlgorithm {
+		case x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1, x509.MD5WithRSA, x509.MD2WithRSA:
+			if !selfSigned {
+				problems = append(problems, fmt.Sprintf("'%s' is signed with %s", cert.Subject.CommonName, cert.SignatureAlgorithm))
+			}
+		}
+		if i == 0 && cert.NotAfter.Sub(cert.NotBefore) > maxCertificateValidity {
+			problems = append(problems, fmt.Sprintf("'%s' is valid for %d days", cert.Subject.CommonName, int(cert.NotAfter.Sub(cert.NotBefore).Hours()/24)))
+		}
+	}
+	return problems
+}
+
+// checkCertificateHygiene warns about weak keys, weak signatures and overly
+// long validity periods.
+func checkCertificateHygiene(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - No server certificate received from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	if problems := certificateWeaknesses(state); len(problems) > 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Weak certificate: %s from host on port %d", strings.Join(problems, ", "), opts.Port),
+			WARNING,
+		}
+	}
+	return "certificate chain passed hygiene checks", nil
+}
+
+// certificateName returns the name the server certificate is expected to
+// be valid for.
+func certificateName(opts commandOpts) string {
+	if opts.ServerName != "" {
+		return opts.ServerName
+	}
+	host, _, err := net.SplitHostPort(opts.Hostname)
+	if err != nil {
+		host = opts.Hostname
+	}
+	host = strings.Trim(host, "[]")
+	host, _, _ = strings.Cut(host, "%")
+	return host
+}
+
+// checkCertificateName reports a certificate not valid for the requested
+// host with --insecure, which would pass silently otherwise.
+func checkCertificateName(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if !opts.Insecure || state == nil || len(state.PeerCertificates) == 0 {
+		return "", nil
+	}
+	name := certificateName(opts)
+	cert := state.PeerCertificates[0]
+	if name == "" || cert.VerifyHostname(name) == nil {
+		return "", nil
+	}
+	sans := strings.Join(certificateSANs(cert), ", ")
+	if sans == "" {
+		sans = "none"
+	}
+	if opts.WarnSANMismatch {
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Certificate '%s' is not valid for %s (SANs: %s) from host on port %d", cert.Subject.CommonName, name, sans, opts.Port),
+			WARNING,
+		}
+	}
+	return fmt.Sprintf("certificate '%s' is not valid for %s (SANs: %s)", cert.Subject.CommonName, name, sans), nil
+}
+
+// oidSCTList is the certificate extension carrying embedded signed
+// certificate timestamps.
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// embeddedSCTCount returns the number of signed certificate timestamps
+// embedded in the certificate.
+func embeddedSCTCount(cert *x509.Certificate) int {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSCTList) {
+			continue
+		}
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil || len(list) < 2 {
+			return 0
+		}
+		count := 0
+		data := list[2:]
+		for len(data) >= 2 {
+			size := int(data[0])<<8 | int(data[1])
+			if len(data) < 2+size {
+				break
+			}
+			data = data[2+size:]
+			count++
+		}
+		return count
+	}
+	return 0
+}
+
+// checkSCT requires signed certificate timestamps from the certificate or
+// the TLS handshake.
+func checkSCT(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No server certificate to check for SCTs from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	embedded := embeddedSCTCount(state.PeerCertificates[0])
+	handshake := len(state.SignedCertificateTimestamps)
+	if embedded+handshake == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No signed certificate timestamps received from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("%d SCT(s) (%d embedded, %d from TLS extension)", embedded+handshake, embedded, handshake), nil
+}
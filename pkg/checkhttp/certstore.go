@@ -0,0 +1,45 @@
+package checkhttp
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// certStoreSelector selects a client certificate from the system store by
+// SHA-1 thumbprint or by a case insensitive subject substring.
+type certStoreSelector struct {
+	thumbprint string
+	subject    string
+}
+
+// parseCertStoreSelector parses `thumbprint:HEX` or `subject:TEXT`,
+// thumbprints may contain spaces or colons as copied from certificate
+// viewers.
+func parseCertStoreSelector(s string) (certStoreSelector, error) {
+	kind, value, ok := strings.Cut(s, ":")
+	if !ok || value == "" {
+		return certStoreSelector{}, fmt.Errorf("expected thumbprint:HEX or subject:TEXT, got %q", s)
+	}
+	switch strings.ToLower(kind) {
+	case "thumbprint":
+		thumbprint := strings.ToLower(strings.NewReplacer(" ", "", ":", "").Replace(value))
+		if _, err := hex.DecodeString(thumbprint); err != nil || len(thumbprint) != 2*sha1.Size {
+			return certStoreSelector{}, fmt.Errorf("invalid SHA-1 thumbprint %q", value)
+		}
+		return certStoreSelector{thumbprint: thumbprint}, nil
+	case "subject":
+		return certStoreSelector{subject: strings.ToLower(value)}, nil
+	}
+	return certStoreSelector{}, fmt.Errorf("unknown selector %q, expected thumbprint or subject", kind)
+}
+
+func (s certStoreSelector) matches(cert *x509.Certificate) bool {
+	if s.thumbprint != "" {
+		sum := sha1.Sum(cert.Raw)
+		return hex.EncodeToString(sum[:]) == s.thumbprint
+	}
+	return strings.Contains(strings.ToLower(cert.Subject.String()), s.subject)
+}
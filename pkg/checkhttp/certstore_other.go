@@ -0,0 +1,12 @@
+//go:build !windows
+
+package checkhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+func loadStoreCertificate(_ certStoreSelector, _ string) (tls.Certificate, error) {
+	return tls.Certificate{}, fmt.Errorf("client certificates from the system certificate store are only supported on Windows, use --cert or --p12 with an exported identity")
+}
@@ -0,0 +1,70 @@
+package checkhttp
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseCertStoreSelector(t *testing.T) {
+	const thumbprint = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		value   string
+		want    certStoreSelector
+		wantErr bool
+	}{
+		{value: "thumbprint:" + thumbprint, want: certStoreSelector{thumbprint: thumbprint}},
+		{value: "Thumbprint:0123456789ABCDEF0123456789ABCDEF01234567", want: certStoreSelector{thumbprint: thumbprint}},
+		{value: "thumbprint:01 23 45 67 89 ab cd ef 01 23 45 67 89 ab cd ef 01 23 45 67", want: certStoreSelector{thumbprint: thumbprint}},
+		{value: "thumbprint:01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67", want: certStoreSelector{thumbprint: thumbprint}},
+		{value: "subject:CN=Monitoring Agent", want: certStoreSelector{subject: "cn=monitoring agent"}},
+		{value: "SUBJECT:Agent", want: certStoreSelector{subject: "agent"}},
+		{value: "thumbprint:0123", wantErr: true},
+		{value: "thumbprint:" + thumbprint + "00", wantErr: true},
+		{value: "thumbprint:zz23456789abcdef0123456789abcdef01234567", wantErr: true},
+		{value: "subject:", wantErr: true},
+		{value: "issuer:CA", wantErr: true},
+		{value: thumbprint, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCertStoreSelector(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCertStoreSelector(%q) = %+v, want error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCertStoreSelector(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestCertStoreSelectorMatches(t *testing.T) {
+	cert := &x509.Certificate{
+		Raw:     []byte("certificate"),
+		Subject: pkix.Name{CommonName: "Monitoring Agent", Organization: []string{"Example"}},
+	}
+	sum := sha1.Sum(cert.Raw)
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "thumbprint:" + hex.EncodeToString(sum[:]), want: true},
+		{selector: "thumbprint:0123456789abcdef0123456789abcdef01234567", want: false},
+		{selector: "subject:monitoring agent", want: true},
+		{selector: "subject:O=Example", want: true},
+		{selector: "subject:other", want: false},
+	}
+	for _, tt := range tests {
+		selector, err := parseCertStoreSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("parseCertStoreSelector(%q): %v", tt.selector, err)
+		}
+		if got := selector.matches(cert); got != tt.want {
+			t.Errorf("%q matches = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+//go:build windows
+
+package checkhttp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+	"unsafe"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	ncrypt               = windows.NewLazySystemDLL("ncrypt.dll")
+	procNCryptSignHash   = ncrypt.NewProc("NCryptSignHash")
+	procNCryptFreeObject = ncrypt.NewProc("NCryptFreeObject")
+)
+
+const (
+	bcryptPadPKCS1 = 0x2
+	bcryptPadPSS   = 0x8
+)
+
+type bcryptPKCS1PaddingInfo struct {
+	algID *uint16
+}
+
+type bcryptPSSPaddingInfo struct {
+	algID *uint16
+	salt  uint32
+}
+
+// loadStoreCertificate finds the certificate in the personal store of the
+// current user or the local machine and returns it with a signer backed by
+// the CNG key, so the private key never leaves the store.
+func loadStoreCertificate(selector certStoreSelector, location string) (tls.Certificate, error) {
+	flags := uint32(windows.CERT_SYSTEM_STORE_CURRENT_USER)
+	if location == "machine" {
+		flags = windows.CERT_SYSTEM_STORE_LOCAL_MACHINE
+	}
+	storeName, err := windows.UTF16PtrFromString("MY")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	store, err := windows.CertOpenStore(windows.CERT_STORE_PROV_SYSTEM, 0, 0,
+		flags|windows.CERT_STORE_OPEN_EXISTING_FLAG|windows.CERT_STORE_READONLY_FLAG, uintptr(unsafe.Pointer(storeName)))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not open certificate store: %v", err)
+	}
+	defer windows.CertCloseStore(store, 0)
+
+	var ctx *windows.CertContext
+	for {
+		ctx, err = windows.CertEnumCertificatesInStore(store, ctx)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("no matching certificate with private key found in store")
+		}
+		raw := unsafe.Slice(ctx.EncodedCert, ctx.Length)
+		cert, err := x509.ParseCertificate(append([]byte{}, raw...))
+		if err != nil || !selector.matches(cert) || time.Now().After(cert.NotAfter) {
+			continue
+		}
+		signer, err := newNCryptSigner(ctx, cert)
+		if err != nil {
+			continue
+		}
+		// ctx is not freed, it owns the cached key handle of the signer
+		return tls.Certificate{
+			Certificate: storeChain(ctx, cert),
+			PrivateKey:  signer,
+			Leaf:        cert,
+		}, nil
+	}
+}
+
+// storeChain returns the leaf followed by the intermediates the system
+// builds for it, so servers which need them accept the client certificate.
+// The root is left out like in chains sent by servers.
+func storeChain(ctx *windows.CertContext, leaf *x509.Certificate) [][]byte {
+	certs := [][]byte{leaf.Raw}
+	para := windows.CertChainPara{}
+	para.Size = uint32(unsafe.Sizeof(para))
+	var chainCtx *windows.CertChainContext
+	if err := windows.CertGetCertificateChain(0, ctx, nil, ctx.Store, &para, 0, 0, &chainCtx); err != nil {
+		return certs
+	}
+	defer windows.CertFreeCertificateChain(chainCtx)
+	if chainCtx.ChainCount == 0 {
+		return certs
+	}
+	chain := unsafe.Slice(chainCtx.Chains, chainCtx.ChainCount)[0]
+	if chain.NumElements < 2 {
+		return certs
+	}
+	for _, element := range unsafe.Slice(chain.Elements, chain.NumElements)[1:] {
+		raw := unsafe.Slice(element.CertContext.EncodedCert, element.CertContext.Length)
+		cert, err := x509.ParseCertificate(append([]byte{}, raw...))
+		if err != nil || bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+			break
+		}
+		certs = append(certs, cert.Raw)
+	}
+	return certs
+}
+
+// ncryptSigner signs with a CNG private key handle.
+type ncryptSigner struct {
+	key windows.Handle
+	pub crypto.PublicKey
+}
+
+func newNCryptSigner(ctx *windows.CertContext, cert *x509.Certificate) (*ncryptSigner, error) {
+	var key windows.Handle
+	var keySpec uint32
+	var callerFree bool
+	err := windows.CryptAcquireCertificatePrivateKey(ctx,
+		windows.CRYPT_ACQUIRE_CACHE_FLAG|windows.CRYPT_ACQUIRE_ONLY_NCRYPT_KEY_FLAG, nil, &key, &keySpec, &callerFree)
+	if err != nil {
+		return nil, err
+	}
+	if keySpec != windows.CERT_NCRYPT_KEY_SPEC {
+		if callerFree {
+			procNCryptFreeObject.Call(uintptr(key))
+		}
+		return nil, fmt.Errorf("certificate key is not a CNG key")
+	}
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", cert.PublicKey)
+	}
+	// with CRYPT_ACQUIRE_CACHE_FLAG the key stays owned by the certificate
+	return &ncryptSigner{key: key, pub: cert.PublicKey}, nil
+}
+
+func (s *ncryptSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *ncryptSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var padding unsafe.Pointer
+	var flags uint32
+	if _, ok := s.pub.(*rsa.PublicKey); ok {
+		algID, err := cngHashAlgorithm(opts.HashFunc())
+		if err != nil {
+			return nil, err
+		}
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			salt := pss.SaltLength
+			if salt == rsa.PSSSaltLengthEqualsHash || salt == rsa.PSSSaltLengthAuto {
+				salt = opts.HashFunc().Size()
+			}
+			padding = unsafe.Pointer(&bcryptPSSPaddingInfo{algID, uint32(salt)})
+			flags = bcryptPadPSS
+		} else {
+			padding = unsafe.Pointer(&bcryptPKCS1PaddingInfo{algID})
+			flags = bcryptPadPKCS1
+		}
+	}
+
+	var size uint32
+	if err := ncryptSignHash(s.key, padding, digest, nil, &size, flags); err != nil {
+		return nil, err
+	}
+	sig := make([]byte, size)
+	if err := ncryptSignHash(s.key, padding, digest, sig, &size, flags); err != nil {
+		return nil, err
+	}
+	sig = sig[:size]
+	if _, ok := s.pub.(*ecdsa.PublicKey); ok {
+		// CNG returns r || s, TLS expects the ASN.1 encoding
+		return ecdsaASN1(sig)
+	}
+	return sig, nil
+}
+
+func ncryptSignHash(key windows.Handle, padding unsafe.Pointer, digest, sig []byte, size *uint32, flags uint32) error {
+	var sigPtr *byte
+	if len(sig) > 0 {
+		sigPtr = &sig[0]
+	}
+	r, _, _ := procNCryptSignHash.Call(uintptr(key), uintptr(padding),
+		uintptr(unsafe.Pointer(&digest[0])), uintptr(len(digest)),
+		uintptr(unsafe.Pointer(sigPtr)), uintptr(len(sig)), uintptr(unsafe.Pointer(size)), uintptr(flags))
+	if r != 0 {
+		return fmt.Errorf("NCryptSignHash failed: %v", windows.Errno(r))
+	}
+	return nil
+}
+
+func cngHashAlgorithm(h crypto.Hash) (*uint16, error) {
+	names := map[crypto.Hash]string{
+		crypto.SHA1:   "SHA1",
+		crypto.SHA256: "SHA256",
+		crypto.SHA384: "SHA384",
+		crypto.SHA512: "SHA512",
+	}
+	name, ok := names[h]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash %v", h)
+	}
+	return windows.UTF16PtrFromString(name)
+}
+
+func ecdsaASN1(sig []byte) ([]byte, error) {
+	half := len(sig) / 2
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[:half]))
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[half:]))
+	})
+	return b.Bytes()
+}
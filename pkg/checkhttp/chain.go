@@ -0,0 +1,116 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxAIAFetches limits the intermediates downloaded to complete a chain.
+const maxAIAFetches = 3
+
+// fetchIssuer downloads the issuing certificate from the authority
+// information access url of cert.
+func fetchIssuer(ctx context.Context, opts commandOpts, cert *x509.Certificate) (*x509.Certificate, error) {
+	var lastErr error
+	for _, url := range cert.IssuingCertificateURL {
+		issuer, err := fetchCertificate(ctx, opts, url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return issuer, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no issuer url")
+	}
+	return nil, lastErr
+}
+
+// fetchCertificate downloads a DER or PEM encoded certificate from url.
+func fetchCertificate(ctx context.Context, opts commandOpts, url string) (*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	res, err := opts.fetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate from %s: %v", url, err)
+	}
+	return cert, nil
+}
+
+// checkChainComplete verifies the server sends all intermediates needed to
+// build a chain to a trusted root by itself.
+func checkChainComplete(ctx context.Context, opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - No server certificate received from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	// explicit roots keep the verifier from completing the chain with
+	// cached or downloaded intermediates. Without --ca-file the Windows and
+	// macOS system pool still defers to the platform verifier.
+	roots, err := verifyRoots(opts)
+	if err != nil {
+		return "", &reqError{fmt.Sprintf("HTTP UNKNOWN - %v", err), UNKNOWN}
+	}
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	verify := func() error {
+		_, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+	err = verify()
+	if err == nil {
+		return fmt.Sprintf("certificate chain complete (%d certificates)", len(state.PeerCertificates)), nil
+	}
+
+	// try to complete the chain like browsers do
+	var missing []string
+	last := state.PeerCertificates[len(state.PeerCertificates)-1]
+	for i := 0; i < maxAIAFetches && err != nil; i++ {
+		issuer, fetchErr := fetchIssuer(ctx, opts, last)
+		if fetchErr != nil {
+			break
+		}
+		missing = append(missing, issuer.Subject.CommonName)
+		intermediates.AddCert(issuer)
+		last = issuer
+		err = verify()
+	}
+	if err == nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Incomplete certificate chain, missing intermediate %s from host on port %d", strings.Join(missing, ", "), opts.Port),
+			WARNING,
+		}
+	}
+	return "", &reqError{
+		fmt.Sprintf("HTTP CRITICAL - Certificate chain cannot be verified from host on port %d: %v", opts.Port, err),
+		CRITICAL,
+	}
+}
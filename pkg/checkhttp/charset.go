@@ -0,0 +1,79 @@
+package checkhttp
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// charsetSniffLen is the amount of body data used to look for a meta tag.
+const charsetSniffLen = 1024
+
+// charsetWriter transcodes the written body into UTF-8 before passing it to
+// dst. The charset is either given explicitly or detected from the
+// Content-Type header and the first bytes of the body.
+type charsetWriter struct {
+	dst         io.Writer
+	contentType string
+	encoding    encoding.Encoding
+	name        string
+	sniff       []byte
+	w           io.WriteCloser
+}
+
+func newCharsetWriter(dst io.Writer, name string, contentType string) (*charsetWriter, error) {
+	cw := &charsetWriter{
+		dst:         dst,
+		contentType: contentType,
+	}
+	if name != "auto" {
+		enc, canonical := charset.Lookup(name)
+		if enc == nil {
+			return nil, fmt.Errorf("unknown charset %q", name)
+		}
+		cw.encoding = enc
+		cw.name = canonical
+	}
+	return cw, nil
+}
+
+func (cw *charsetWriter) start() error {
+	if cw.encoding == nil {
+		cw.encoding, cw.name, _ = charset.DetermineEncoding(cw.sniff, cw.contentType)
+	}
+	cw.w = transform.NewWriter(cw.dst, cw.encoding.NewDecoder())
+	_, err := cw.w.Write(cw.sniff)
+	cw.sniff = nil
+	return err
+}
+
+func (cw *charsetWriter) Write(p []byte) (int, error) {
+	if cw.w != nil {
+		return cw.w.Write(p)
+	}
+	cw.sniff = append(cw.sniff, p...)
+	if len(cw.sniff) >= charsetSniffLen {
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close flushes remaining data, it must be called after the body has been read.
+func (cw *charsetWriter) Close() error {
+	if cw.w == nil {
+		if err := cw.start(); err != nil {
+			return err
+		}
+	}
+	return cw.w.Close()
+}
+
+// Charset returns the name of the used charset.
+func (cw *charsetWriter) Charset() string {
+	return cw.name
+}
@@ -0,0 +1,106 @@
+package checkhttp
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/sni/go-flags"
+)
+
+// completionOptions returns all visible options of the parser.
+func completionOptions(psr *flags.Parser) []*flags.Option {
+	var options []*flags.Option
+	var walk func(g *flags.Group)
+	walk = func(g *flags.Group) {
+		for _, opt := range g.Options() {
+			if !opt.Hidden {
+				options = append(options, opt)
+			}
+		}
+		for _, sub := range g.Groups() {
+			walk(sub)
+		}
+	}
+	walk(psr.Command.Group)
+	return options
+}
+
+// takesValue returns true if the option expects an argument.
+func takesValue(opt *flags.Option) bool {
+	kind := opt.Field().Type.Kind()
+	if kind == reflect.Slice {
+		kind = opt.Field().Type.Elem().Kind()
+	}
+	return kind != reflect.Bool && !opt.OptionalArgument
+}
+
+func optionNames(opt *flags.Option) []string {
+	var names []string
+	if opt.ShortName != 0 {
+		names = append(names, "-"+string(opt.ShortName))
+	}
+	if opt.LongName != "" {
+		names = append(names, "--"+opt.LongName)
+	}
+	return names
+}
+
+// writeCompletion prints a completion script for the given shell, generated
+// from the option definitions.
+func writeCompletion(output io.Writer, psr *flags.Parser, shell string) {
+	options := completionOptions(psr)
+	name := psr.Name
+	switch shell {
+	case "bash":
+		var words []string
+		fmt.Fprintf(output, "_%s() {\n\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\tcase \"$prev\" in\n", name)
+		for _, opt := range options {
+			names := optionNames(opt)
+			words = append(words, names...)
+			if len(opt.Choices) > 0 {
+				fmt.Fprintf(output, "\t%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\t\t;;\n", strings.Join(names, "|"), strings.Join(opt.Choices, " "))
+			} else if takesValue(opt) {
+				fmt.Fprintf(output, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn\n\t\t;;\n", strings.Join(names, "|"))
+			}
+		}
+		fmt.Fprintf(output, "\tesac\n\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n}\ncomplete -F _%s %s\n", strings.Join(words, " "), name, name)
+	case "zsh":
+		fmt.Fprintf(output, "#compdef %s\n\n_arguments \\\n", name)
+		escape := strings.NewReplacer("[", "\\[", "]", "\\]", "'", "'\\''", ":", "\\:")
+		for _, opt := range options {
+			value := ""
+			if len(opt.Choices) > 0 {
+				value = fmt.Sprintf(":%s:(%s)", opt.LongName, strings.Join(opt.Choices, " "))
+			} else if takesValue(opt) {
+				value = fmt.Sprintf(":%s:_files", opt.LongName)
+			}
+			desc := escape.Replace(opt.Description)
+			for _, n := range optionNames(opt) {
+				if takesValue(opt) && strings.HasPrefix(n, "--") {
+					n += "="
+				}
+				fmt.Fprintf(output, "\t'%s[%s]%s' \\\n", n, desc, value)
+			}
+		}
+		fmt.Fprintf(output, "\t&& return 0\n")
+	case "fish":
+		for _, opt := range options {
+			line := fmt.Sprintf("complete -c %s", name)
+			if opt.ShortName != 0 {
+				line += fmt.Sprintf(" -s %s", string(opt.ShortName))
+			}
+			if opt.LongName != "" {
+				line += fmt.Sprintf(" -l %s", opt.LongName)
+			}
+			if len(opt.Choices) > 0 {
+				line += fmt.Sprintf(" -x -a '%s'", strings.Join(opt.Choices, " "))
+			} else if takesValue(opt) {
+				line += " -r"
+			}
+			line += fmt.Sprintf(" -d '%s'", strings.ReplaceAll(opt.Description, "'", "\\'"))
+			fmt.Fprintln(output, line)
+		}
+	}
+}
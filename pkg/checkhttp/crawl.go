@@ -0,0 +1,129 @@
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type crawlTarget struct {
+	URL   *url.URL
+	Depth int
+}
+
+type crawlResult struct {
+	Checked int
+	Broken  []string
+	Skipped int
+}
+
+// crawl verifies the links found on the start page breadth first until
+// --crawl-depth, the --crawl-max-urls budget or the --crawl-timeout is
+// reached. Only pages on the start host are crawled further, other hosts are
+// only checked (or skipped with --crawl-same-host).
+func crawl(ctx context.Context, client *http.Client, opts commandOpts, start *http.Request, page []byte) crawlResult {
+	result := crawlResult{}
+	seen := map[string]bool{canonicalURL(start.URL): true}
+	var queue []crawlTarget
+	enqueue := func(base *url.URL, page []byte, depth int) {
+		for _, href := range extractLinks(page) {
+			u, err := base.Parse(href)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			if opts.CrawlSameHost && u.Host != start.URL.Host {
+				continue
+			}
+			key := canonicalURL(u)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			queue = append(queue, crawlTarget{u, depth})
+		}
+	}
+	enqueue(start.URL, page, 1)
+
+	for len(queue) > 0 {
+		if result.Checked >= opts.CrawlMaxURLs || ctx.Err() != nil {
+			result.Skipped = len(queue)
+			break
+		}
+		target := queue[0]
+		queue = queue[1:]
+		result.Checked++
+
+		res, body, err := fetchLink(ctx, client, opts, start, target.URL)
+		if err != nil && ctx.Err() != nil {
+			// the crawl budget ran out, the link was not really checked
+			result.Checked--
+			result.Skipped = len(queue) + 1
+			break
+		}
+		if err != nil {
+			result.Broken = append(result.Broken, fmt.Sprintf("%s: %v", target.URL, err))
+			continue
+		}
+		if target.Depth < opts.CrawlDepth && target.URL.Host == start.URL.Host &&
+			strings.Contains(res.Header.Get("Content-Type"), "html") {
+			enqueue(target.URL, body, target.Depth+1)
+		}
+	}
+	return result
+}
+
+// fetchLink GETs a url found on a checked page and returns an error for
+// failed requests and status codes >= 400. The body is read up to the
+// max-buffer-size.
+func fetchLink(ctx context.Context, client *http.Client, opts commandOpts, from *http.Request, u *url.URL) (*http.Response, []byte, error) {
+	req, err := redirectRequest(ctx, opts, from, u, http.StatusSeeOther)
+	if err != nil {
+		return nil, nil, err
+	}
+	// bound by the crawl budget instead of the traced start request
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, int64(opts.bufferSize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("%s", res.Status)
+	}
+	return res, body, nil
+}
+
+// canonicalURL strips the fragment, which does not change the fetched document.
+func canonicalURL(u *url.URL) string {
+	c := *u
+	c.Fragment = ""
+	c.RawFragment = ""
+	return c.String()
+}
+
+func checkCrawl(ctx context.Context, client *http.Client, opts commandOpts, start *http.Request, page []byte) (string, []string, *reqError) {
+	ctx, cancel := context.WithTimeout(ctx, opts.CrawlTimeout)
+	defer cancel()
+	result := crawl(ctx, client, opts, start, page)
+	perfdata := []string{
+		fmt.Sprintf("crawled=%d;;;0;", result.Checked),
+		fmt.Sprintf("broken=%d;;0;0;", len(result.Broken)),
+	}
+	if len(result.Broken) > 0 {
+		return "", perfdata, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %d of %d crawled links broken from host on port %d\n%s", len(result.Broken), result.Checked, opts.Port, strings.Join(result.Broken, "\n")),
+			CRITICAL,
+		}
+	}
+	msg := fmt.Sprintf("%d crawled links ok", result.Checked)
+	if result.Skipped > 0 {
+		msg += fmt.Sprintf(" (%d skipped)", result.Skipped)
+	}
+	return msg, perfdata, nil
+}
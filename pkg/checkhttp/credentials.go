@@ -0,0 +1,179 @@
+package checkhttp
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// credential environment variables used when no credentials are given on
+// the command line, which would be visible in the process list.
+const (
+	envAuthorization      = "CHECK_HTTP_AUTHORIZATION"
+	envBearerToken        = "CHECK_HTTP_BEARER_TOKEN"
+	envDigest             = "CHECK_HTTP_DIGEST"
+	envProxyAuth          = "CHECK_HTTP_PROXY_AUTH"
+	envOAuth2ClientSecret = "CHECK_HTTP_OAUTH2_CLIENT_SECRET"
+)
+
+// netrcEntry holds the login of a netrc machine or default entry.
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// parseNetrc parses the machine and default entries of a netrc file,
+// macdef macros are skipped.
+func parseNetrc(data string) []netrcEntry {
+	var entries []netrcEntry
+	var tokens []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// macros end with an empty line
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			if strings.HasPrefix(f, "#") {
+				fields = fields[:i]
+				break
+			}
+		}
+		for _, f := range fields {
+			if f == "macdef" {
+				inMacro = true
+				break
+			}
+			tokens = append(tokens, f)
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				i++
+				entries = append(entries, netrcEntry{machine: tokens[i]})
+			}
+		case "default":
+			entries = append(entries, netrcEntry{})
+		case "login", "password", "account":
+			if i+1 >= len(tokens) || len(entries) == 0 {
+				continue
+			}
+			i++
+			if tokens[i-1] == "login" {
+				entries[len(entries)-1].login = tokens[i]
+			} else if tokens[i-1] == "password" {
+				entries[len(entries)-1].password = tokens[i]
+			}
+		}
+	}
+	return entries
+}
+
+// netrcCredentials returns the `login:password` for host from the netrc
+// file, the first matching machine or the default entry wins.
+func netrcCredentials(path, host string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	for _, e := range parseNetrc(string(data)) {
+		if e.machine == "" || strings.EqualFold(e.machine, host) {
+			return e.login + ":" + e.password, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// defaultNetrcPath returns $NETRC or ~/.netrc.
+func defaultNetrcPath() (string, error) {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// loadCredentials reads the credentials from --authorization-file and
+// --digest-file.
+func loadCredentials(opts *commandOpts) error {
+	if opts.AuthorizationFile != "" {
+		if opts.Authorization != "" {
+			return fmt.Errorf("both authorization and authorization-file are specified")
+		}
+		data, err := os.ReadFile(opts.AuthorizationFile)
+		if err != nil {
+			return fmt.Errorf("could not read authorization-file: %v", err)
+		}
+		opts.Authorization = strings.TrimSpace(string(data))
+	}
+	if opts.DigestFile != "" {
+		if opts.Digest != "" {
+			return fmt.Errorf("both digest and digest-file are specified")
+		}
+		data, err := os.ReadFile(opts.DigestFile)
+		if err != nil {
+			return fmt.Errorf("could not read digest-file: %v", err)
+		}
+		opts.Digest = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// defaultCredentials fills the credentials from netrc and the environment
+// when none are given. It needs the final hostname, so it runs after the
+// host options are normalized.
+func defaultCredentials(opts *commandOpts) error {
+	explicit := opts.Authorization != "" || opts.BearerToken != "" || opts.BearerTokenFile != "" ||
+		opts.Digest != "" || opts.OAuth2TokenURL != "" || opts.AWSSigV4 != "" || opts.ExpectAuthScheme != ""
+	if (opts.Netrc || opts.NetrcFile != "") && !explicit {
+		path := opts.NetrcFile
+		if path == "" {
+			var err error
+			if path, err = defaultNetrcPath(); err != nil {
+				return fmt.Errorf("could not locate netrc: %v", err)
+			}
+		}
+		host := opts.Hostname
+		if u, err := url.Parse(requestURL(*opts)); err == nil {
+			host = u.Hostname()
+		}
+		auth, ok, err := netrcCredentials(path, host)
+		if err != nil {
+			return fmt.Errorf("could not read netrc: %v", err)
+		}
+		if ok {
+			opts.Authorization = auth
+			explicit = true
+		}
+	}
+
+	if !explicit {
+		opts.Authorization = os.Getenv(envAuthorization)
+		if opts.Authorization == "" {
+			opts.BearerToken = os.Getenv(envBearerToken)
+		}
+		if opts.Authorization == "" && opts.BearerToken == "" {
+			opts.Digest = os.Getenv(envDigest)
+		}
+	}
+	if opts.ProxyAuth == "" {
+		opts.ProxyAuth = os.Getenv(envProxyAuth)
+	}
+	if opts.OAuth2ClientSecret == "" {
+		opts.OAuth2ClientSecret = os.Getenv(envOAuth2ClientSecret)
+	}
+	return nil
+}
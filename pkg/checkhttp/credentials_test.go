@@ -0,0 +1,215 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		data string
+		want []netrcEntry
+	}{
+		{
+			data: "machine example.com login user password secret\n",
+			want: []netrcEntry{{"example.com", "user", "secret"}},
+		},
+		{
+			data: "machine a login u1 password p1 machine b\nlogin u2\npassword p2\ndefault login d password dp\n",
+			want: []netrcEntry{{"a", "u1", "p1"}, {"b", "u2", "p2"}, {"", "d", "dp"}},
+		},
+		{
+			data: "# comment\nmachine a login u # trailing comment\n  password p\n",
+			want: []netrcEntry{{"a", "u", "p"}},
+		},
+		{
+			data: "machine a login u account acc password p\n",
+			want: []netrcEntry{{"a", "u", "p"}},
+		},
+		{
+			data: "macdef init\ncd /pub\nmachine skipped login x\n\nmachine b login u password p\n",
+			want: []netrcEntry{{"b", "u", "p"}},
+		},
+		{
+			data: "login orphan password x\nmachine\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		got := parseNetrc(tt.data)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNetrc(%q) = %+v, want %+v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNetrcCredentials(t *testing.T) {
+	path := writeTestFile(t, "netrc", "machine Example.com login a password 1\n"+
+		"machine example.com login b password 2\n"+
+		"machine xn--bcher-kva.de login idn password 3\n"+
+		"default login def password 4\n")
+	tests := []struct {
+		host   string
+		want   string
+		wantOK bool
+	}{
+		{host: "example.com", want: "a:1", wantOK: true},
+		{host: "EXAMPLE.COM", want: "a:1", wantOK: true},
+		{host: "xn--bcher-kva.de", want: "idn:3", wantOK: true},
+		{host: "other.example", want: "def:4", wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok, err := netrcCredentials(path, tt.host)
+		if err != nil || ok != tt.wantOK || got != tt.want {
+			t.Errorf("netrcCredentials(%q) = %q, %v, %v, want %q, %v", tt.host, got, ok, err, tt.want, tt.wantOK)
+		}
+	}
+
+	noDefault := writeTestFile(t, "netrc", "machine example.com login a password 1\n")
+	if got, ok, err := netrcCredentials(noDefault, "other.example"); ok || err != nil {
+		t.Errorf("netrcCredentials without default = %q, %v, %v, want no match", got, ok, err)
+	}
+	if _, _, err := netrcCredentials(filepath.Join(t.TempDir(), "missing"), "example.com"); err == nil {
+		t.Errorf("netrcCredentials with missing file succeeded")
+	}
+}
+
+func TestLoadCredentials(t *testing.T) {
+	authFile := writeTestFile(t, "auth", "user:pass\n")
+	digestFile := writeTestFile(t, "digest", " duser:dpass \n")
+	tests := []struct {
+		name       string
+		opts       commandOpts
+		wantAuth   string
+		wantDigest string
+		wantErr    bool
+	}{
+		{name: "none"},
+		{name: "authorization file", opts: commandOpts{AuthorizationFile: authFile}, wantAuth: "user:pass"},
+		{name: "digest file", opts: commandOpts{DigestFile: digestFile}, wantDigest: "duser:dpass"},
+		{name: "authorization twice", opts: commandOpts{Authorization: "a:b", AuthorizationFile: authFile}, wantErr: true},
+		{name: "digest twice", opts: commandOpts{Digest: "a:b", DigestFile: digestFile}, wantErr: true},
+		{name: "missing file", opts: commandOpts{DigestFile: filepath.Join(t.TempDir(), "missing")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		err := loadCredentials(&opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: loadCredentials error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (opts.Authorization != tt.wantAuth || opts.Digest != tt.wantDigest) {
+			t.Errorf("%s: got authorization %q digest %q, want %q %q", tt.name, opts.Authorization, opts.Digest, tt.wantAuth, tt.wantDigest)
+		}
+	}
+}
+
+func TestDefaultCredentials(t *testing.T) {
+	netrc := writeTestFile(t, "netrc", "machine example.com login n password p\n")
+	tests := []struct {
+		name       string
+		opts       commandOpts
+		env        map[string]string
+		wantAuth   string
+		wantBearer string
+		wantDigest string
+	}{
+		{
+			name:     "netrc",
+			opts:     commandOpts{Hostname: "example.com", URI: "/", NetrcFile: netrc},
+			env:      map[string]string{envAuthorization: "env:auth"},
+			wantAuth: "n:p",
+		},
+		{
+			name:     "netrc without match falls back to environment",
+			opts:     commandOpts{Hostname: "other.example", URI: "/", NetrcFile: netrc},
+			env:      map[string]string{envAuthorization: "env:auth"},
+			wantAuth: "env:auth",
+		},
+		{
+			name:     "explicit wins over netrc and environment",
+			opts:     commandOpts{Hostname: "example.com", URI: "/", NetrcFile: netrc, Authorization: "cli:auth"},
+			env:      map[string]string{envAuthorization: "env:auth"},
+			wantAuth: "cli:auth",
+		},
+		{
+			name:       "explicit digest ignores environment",
+			opts:       commandOpts{Hostname: "example.com", URI: "/", Digest: "cli:digest"},
+			env:        map[string]string{envAuthorization: "env:auth"},
+			wantDigest: "cli:digest",
+		},
+		{
+			name:       "bearer token from environment",
+			opts:       commandOpts{Hostname: "example.com", URI: "/"},
+			env:        map[string]string{envBearerToken: "token", envDigest: "env:digest"},
+			wantBearer: "token",
+		},
+		{
+			name:       "digest from environment",
+			opts:       commandOpts{Hostname: "example.com", URI: "/"},
+			env:        map[string]string{envDigest: "env:digest"},
+			wantDigest: "env:digest",
+		},
+	}
+	for _, tt := range tests {
+		for _, name := range []string{envAuthorization, envBearerToken, envDigest} {
+			t.Setenv(name, tt.env[name])
+		}
+		opts := tt.opts
+		if err := defaultCredentials(&opts); err != nil {
+			t.Errorf("%s: defaultCredentials failed: %v", tt.name, err)
+			continue
+		}
+		if opts.Authorization != tt.wantAuth || opts.BearerToken != tt.wantBearer || opts.Digest != tt.wantDigest {
+			t.Errorf("%s: got authorization %q bearer %q digest %q, want %q %q %q", tt.name,
+				opts.Authorization, opts.BearerToken, opts.Digest, tt.wantAuth, tt.wantBearer, tt.wantDigest)
+		}
+	}
+}
+
+// TestCheckNetrcHost verifies netrc is looked up with the normalized host,
+// i.e. the address given with -I and the punycode form of IDN hosts.
+func TestCheckNetrcHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		fmt.Fprintf(w, "auth=%s:%s", user, password)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	netrc := writeTestFile(t, "netrc", "machine 127.0.0.1 login ip password 1\n"+
+		"machine xn--bcher-kva.de login idn password 2\n"+
+		"default login def password 3\n")
+	t.Setenv(envAuthorization, "")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"-I", "127.0.0.1"}, want: "auth=ip:1"},
+		{args: []string{"-H", "bücher.de", "-I", "127.0.0.1"}, want: "auth=idn:2"},
+		{args: []string{"-H", "other.example", "-I", "127.0.0.1"}, want: "auth=def:3"},
+	}
+	for _, tt := range tests {
+		args := append(tt.args, "-p", port, "--netrc-file", netrc, "-s", tt.want)
+		var output bytes.Buffer
+		if state := Check(context.Background(), &output, args); state != OK {
+			t.Errorf("Check(%q) = %d: %s", args, state, output.String())
+		}
+	}
+}
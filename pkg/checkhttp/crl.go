@@ -0,0 +1,161 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCRLSize limits the size of downloaded revocation lists.
+const maxCRLSize = 32 * 1024 * 1024
+
+// crlCache keeps downloaded revocation lists for the duration of a run, so
+// intermediates sharing a distribution point and repeated attempts only
+// fetch them once.
+type crlCache struct {
+	mu    sync.Mutex
+	lists map[string]*x509.RevocationList
+}
+
+func newCRLCache() *crlCache {
+	return &crlCache{lists: map[string]*x509.RevocationList{}}
+}
+
+func (c *crlCache) fetch(ctx context.Context, opts commandOpts, url string) (*x509.RevocationList, error) {
+	c.mu.Lock()
+	crl, ok := c.lists[url]
+	c.mu.Unlock()
+	if ok {
+		return crl, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.CRLTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	res, err := opts.fetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, res.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxCRLSize))
+	if err != nil {
+		return nil, err
+	}
+	crl, err = x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL from %s: %v", url, err)
+	}
+	c.mu.Lock()
+	c.lists[url] = crl
+	c.mu.Unlock()
+	return crl, nil
+}
+
+// verifiedIssuers returns the issuer of each presented certificate, taken from
+// the verified chain or, with --insecure, from a chain built against the
+// trusted roots. Certificates outside of the chain have no issuer.
+func verifiedIssuers(opts commandOpts, state *tls.ConnectionState) []*x509.Certificate {
+	var chain []*x509.Certificate
+	if len(state.VerifiedChains) > 0 {
+		chain = state.VerifiedChains[0]
+	} else if roots, err := verifyRoots(opts); err == nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		chains, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		if err == nil {
+			chain = chains[0]
+		}
+	}
+	issuers := make([]*x509.Certificate, len(state.PeerCertificates))
+	for i, cert := range state.PeerCertificates {
+		for j, c := range chain {
+			if c.Equal(cert) {
+				if j+1 < len(chain) {
+					issuers[i] = chain[j+1]
+				} else {
+					issuers[i] = c
+				}
+				break
+			}
+		}
+	}
+	return issuers
+}
+
+// checkCRL downloads the CRL distribution points of each presented
+// certificate and fails when one of them is revoked. An outdated CRL is
+// reported as warning.
+func checkCRL(ctx context.Context, opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - No server certificate received from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	issuers := verifiedIssuers(opts, state)
+	checked := 0
+	var outdated []string
+	for i, cert := range state.PeerCertificates {
+		for _, url := range cert.CRLDistributionPoints {
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				continue
+			}
+			if issuers[i] == nil {
+				return "", &reqError{
+					fmt.Sprintf("HTTP UNKNOWN - Could not verify CRL %s, issuer of '%s' not found from host on port %d", url, cert.Subject.CommonName, opts.Port),
+					UNKNOWN,
+				}
+			}
+			crl, err := opts.crls.fetch(ctx, opts, url)
+			if err != nil {
+				return "", &reqError{
+					fmt.Sprintf("HTTP UNKNOWN - Could not fetch CRL: %v", err),
+					UNKNOWN,
+				}
+			}
+			if err := crl.CheckSignatureFrom(issuers[i]); err != nil {
+				return "", &reqError{
+					fmt.Sprintf("HTTP CRITICAL - CRL %s is not signed by the issuer of '%s': %v", url, cert.Subject.CommonName, err),
+					CRITICAL,
+				}
+			}
+			for _, revoked := range crl.RevokedCertificateEntries {
+				if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return "", &reqError{
+						fmt.Sprintf("HTTP CRITICAL - Certificate '%s' revoked on %s according to CRL %s from host on port %d", cert.Subject.CommonName, revoked.RevocationTime.UTC().Format("2006-01-02 15:04 MST"), url, opts.Port),
+						CRITICAL,
+					}
+				}
+			}
+			if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+				outdated = append(outdated, fmt.Sprintf("%s (next update %s)", url, crl.NextUpdate.UTC().Format("2006-01-02 15:04 MST")))
+			}
+			checked++
+			break
+		}
+	}
+	if len(outdated) > 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Outdated CRL %s from host on port %d", strings.Join(outdated, ", "), opts.Port),
+			WARNING,
+		}
+	}
+	if checked == 0 {
+		return "no CRL distribution points", nil
+	}
+	return fmt.Sprintf("%d certificate(s) not revoked by CRL", checked), nil
+}
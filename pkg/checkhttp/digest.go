@@ -0,0 +1,126 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// digestHashes lists the supported digest algorithms, most preferred first.
+var digestHashes = []struct {
+	name string
+	hash func() hash.Hash
+}{
+	{"SHA-256", sha256.New},
+	{"MD5", md5.New},
+}
+
+// digestChallenge picks the strongest supported Digest challenge and
+// returns it with its hash function.
+func digestChallenge(res *http.Response) (authChallenge, func() hash.Hash, bool) {
+	challenges := parseAuthChallenges(res.Header)
+	for _, h := range digestHashes {
+		for _, c := range challenges {
+			if !strings.EqualFold(c.scheme, "Digest") || c.params["nonce"] == "" {
+				continue
+			}
+			algo := strings.TrimSuffix(strings.ToUpper(c.params["algorithm"]), "-SESS")
+			if algo == "" {
+				algo = "MD5"
+			}
+			if algo == h.name {
+				return c, h.hash, true
+			}
+		}
+	}
+	return authChallenge{}, nil, false
+}
+
+// digestAuthorization computes the RFC 7616 Authorization header answering
+// the challenge c with the client nonce cnonce.
+func digestAuthorization(c authChallenge, newHash func() hash.Hash, user, password, method, uri, cnonce string) (string, error) {
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	qop := ""
+	if c.params["qop"] != "" {
+		for _, q := range strings.Split(c.params["qop"], ",") {
+			if strings.TrimSpace(q) == "auth" {
+				qop = "auth"
+			}
+		}
+		if qop == "" {
+			return "", fmt.Errorf("unsupported digest qop %q", c.params["qop"])
+		}
+	}
+	const nc = "00000001"
+
+	realm, nonce := c.params["realm"], c.params["nonce"]
+	ha1 := h(user + ":" + realm + ":" + password)
+	if strings.HasSuffix(strings.ToUpper(c.params["algorithm"]), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+	var response string
+	if qop != "" {
+		response = h(strings.Join([]string{ha1, nonce, nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf("username=%q", user),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("response=%q", response),
+	}
+	if algo := c.params["algorithm"]; algo != "" {
+		fields = append(fields, "algorithm="+algo)
+	}
+	if qop != "" {
+		fields = append(fields, "qop="+qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if opaque, ok := c.params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// digestRequest returns the request repeated with Digest credentials when
+// res carries a Digest challenge, nil otherwise.
+func digestRequest(ctx context.Context, opts commandOpts, req *http.Request, res *http.Response) (*http.Request, error) {
+	if res.StatusCode != http.StatusUnauthorized {
+		return nil, nil
+	}
+	c, newHash, ok := digestChallenge(res)
+	if !ok {
+		return nil, nil
+	}
+	user, password, ok := strings.Cut(opts.Digest, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid digest args")
+	}
+	next, err := redirectRequest(ctx, opts, req, req.URL, http.StatusTemporaryRedirect)
+	if err != nil {
+		return nil, err
+	}
+	var raw [12]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, err
+	}
+	auth, err := digestAuthorization(c, newHash, user, password, next.Method, next.URL.RequestURI(), hex.EncodeToString(raw[:]))
+	if err != nil {
+		return nil, err
+	}
+	next.Header.Set("Authorization", auth)
+	return next, nil
+}
@@ -0,0 +1,63 @@
+package checkhttp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"hash"
+	"testing"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	// example of RFC 7616 section 3.9.1
+	const (
+		realm  = "http-auth@example.org"
+		nonce  = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+		opaque = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+		cnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	)
+	fields := `username="Mufasa", realm="` + realm + `", nonce="` + nonce + `", uri="/dir/index.html", `
+	tests := []struct {
+		algorithm string
+		qop       string
+		newHash   func() hash.Hash
+		want      string
+	}{
+		{
+			algorithm: "MD5", qop: "auth, auth-int", newHash: md5.New,
+			want: "Digest " + fields + `response="8ca523f5e9506fed4657c9700eebdbec", algorithm=MD5, ` +
+				`qop=auth, nc=00000001, cnonce="` + cnonce + `", opaque="` + opaque + `"`,
+		},
+		{
+			algorithm: "SHA-256", qop: "auth, auth-int", newHash: sha256.New,
+			want: "Digest " + fields + `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", algorithm=SHA-256, ` +
+				`qop=auth, nc=00000001, cnonce="` + cnonce + `", opaque="` + opaque + `"`,
+		},
+		{
+			// without qop the response is H(HA1:nonce:HA2) as in RFC 2069
+			algorithm: "", qop: "", newHash: md5.New,
+			want: "Digest " + fields + `response="7b2cc3b30e75b4777ea31027084363fd", opaque="` + opaque + `"`,
+		},
+	}
+	for _, tt := range tests {
+		c := authChallenge{scheme: "Digest", params: map[string]string{"realm": realm, "nonce": nonce, "opaque": opaque}}
+		if tt.algorithm != "" {
+			c.params["algorithm"] = tt.algorithm
+		}
+		if tt.qop != "" {
+			c.params["qop"] = tt.qop
+		}
+		got, err := digestAuthorization(c, tt.newHash, "Mufasa", "Circle of Life", "GET", "/dir/index.html", cnonce)
+		if err != nil {
+			t.Errorf("%s: digestAuthorization failed: %v", tt.algorithm, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: digestAuthorization =\n%s\nwant\n%s", tt.algorithm, got, tt.want)
+		}
+	}
+
+	c := authChallenge{scheme: "Digest", params: map[string]string{"realm": realm, "nonce": nonce, "qop": "auth-int"}}
+	if _, err := digestAuthorization(c, md5.New, "Mufasa", "Circle of Life", "GET", "/", cnonce); err == nil {
+		t.Errorf("digestAuthorization with qop auth-int only succeeded, want error")
+	}
+}
@@ -0,0 +1,58 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// checkFavicon fetches the icon declared by the page (or /favicon.ico) and
+// verifies status, content type and optionally its SHA-256 checksum.
+func checkFavicon(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request, page []byte) (string, *reqError) {
+	href := "/favicon.ico"
+	if icon, ok := findIcon(page); ok {
+		href = icon
+	}
+	u, err := req.URL.Parse(href)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid favicon url %q: %v", href, err),
+			CRITICAL,
+		}
+	}
+
+	res, body, err := fetchLink(ctx, client, opts, req, u)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Favicon %s: %v", u, err),
+			CRITICAL,
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Favicon %s: %s", u, res.Status),
+			CRITICAL,
+		}
+	}
+	contentType := res.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Favicon %s has no image content type: %q", u, contentType),
+			CRITICAL,
+		}
+	}
+	if opts.CheckFavicon != "any" {
+		sum := sha256.Sum256(body)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), opts.CheckFavicon) {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Favicon %s checksum %s did not match %s", u, hex.EncodeToString(sum[:]), opts.CheckFavicon),
+				CRITICAL,
+			}
+		}
+		return fmt.Sprintf("Favicon %s checksum matched", u), nil
+	}
+	return fmt.Sprintf("Favicon %s found", u), nil
+}
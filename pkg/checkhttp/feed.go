@@ -0,0 +1,112 @@
+package checkhttp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type feedDoc struct {
+	XMLName xml.Name
+	// RSS 2.0 / 0.9x
+	Channel struct {
+		Items []feedEntry `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 (RDF) has the items on the top level
+	Items []feedEntry `xml:"item"`
+	// Atom
+	Updated string      `xml:"updated"`
+	Entries []feedEntry `xml:"entry"`
+}
+
+type feedEntry struct {
+	PubDate   string `xml:"pubDate"`
+	Date      string `xml:"date"`
+	Updated   string `xml:"updated"`
+	Published string `xml:"published"`
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+}
+
+func parseFeedTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newestFeedEntry parses an RSS or Atom feed and returns the number of
+// entries and the date of the newest one.
+func newestFeedEntry(data []byte) (int, time.Time, error) {
+	doc := &feedDoc{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return 0, time.Time{}, err
+	}
+	var entries []feedEntry
+	switch doc.XMLName.Local {
+	case "rss":
+		entries = doc.Channel.Items
+	case "RDF":
+		entries = doc.Items
+	case "feed":
+		entries = doc.Entries
+	default:
+		return 0, time.Time{}, fmt.Errorf("unexpected root element <%s>", doc.XMLName.Local)
+	}
+
+	var newest time.Time
+	for _, e := range entries {
+		for _, s := range []string{e.PubDate, e.Date, e.Updated, e.Published} {
+			if t, ok := parseFeedTime(s); ok && t.After(newest) {
+				newest = t
+			}
+		}
+	}
+	if newest.IsZero() {
+		if t, ok := parseFeedTime(doc.Updated); ok {
+			newest = t
+		}
+	}
+	return len(entries), newest, nil
+}
+
+func checkFeed(opts commandOpts, body []byte) (string, []string, *reqError) {
+	count, newest, err := newestFeedEntry(body)
+	if err != nil {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid feed from host on port %d: %v", opts.Port, err),
+			CRITICAL,
+		}
+	}
+	if opts.FeedMaxAge <= 0 {
+		return fmt.Sprintf("Feed with %d entries", count), nil, nil
+	}
+	if newest.IsZero() {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Feed has no dated entries from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	age := time.Since(newest)
+	if age > opts.FeedMaxAge {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Newest feed entry is %s old (> %s) from host on port %d", age.Truncate(time.Second), opts.FeedMaxAge, opts.Port),
+			CRITICAL,
+		}
+	}
+	perfdata := []string{fmt.Sprintf("feed_age=%.0fs;;%.0f;0;", age.Seconds(), opts.FeedMaxAge.Seconds())}
+	return fmt.Sprintf("Feed with %d entries, newest from %s", count, newest.Format(time.RFC3339)), perfdata, nil
+}
@@ -0,0 +1,55 @@
+package checkhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// fipsCipherSuites are the FIPS 140 approved TLS 1.2 cipher suites. TLS 1.3
+// suites cannot be configured and are restricted by the crypto module itself.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// applyFIPS restricts tlsConfig to FIPS approved versions, cipher suites and
+// curves. It fails when the binary does not use a FIPS 140 crypto module.
+func applyFIPS(tlsConfig *tls.Config) error {
+	if _, ok := fipsModule(); !ok {
+		return fmt.Errorf("fips requires a FIPS 140 crypto module (build with GOEXPERIMENT=boringcrypto or run with GODEBUG=fips140=on)")
+	}
+	if tlsConfig.MinVersion < tls.VersionTLS12 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+	if tlsConfig.MaxVersion == 0 || tlsConfig.MaxVersion > fipsMaxTLSVersion {
+		tlsConfig.MaxVersion = fipsMaxTLSVersion
+	}
+	if tlsConfig.MaxVersion < tlsConfig.MinVersion {
+		return fmt.Errorf("fips requires at least TLS 1.2")
+	}
+	tlsConfig.CipherSuites = fipsCipherSuites
+	tlsConfig.CurvePreferences = fipsCurves
+	return nil
+}
+
+// fipsConnection returns a description of the negotiated connection and
+// whether it only uses FIPS approved parameters.
+func fipsConnection(state *tls.ConnectionState) (string, bool) {
+	desc := fmt.Sprintf("%s %s", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	if state.Version < tls.VersionTLS12 {
+		return desc, false
+	}
+	if state.Version == tls.VersionTLS13 {
+		return desc, state.CipherSuite != tls.TLS_CHACHA20_POLY1305_SHA256
+	}
+	for _, id := range fipsCipherSuites {
+		if id == state.CipherSuite {
+			return desc, true
+		}
+	}
+	return desc, false
+}
@@ -0,0 +1,14 @@
+//go:build go1.24 && !boringcrypto
+
+package checkhttp
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+)
+
+const fipsMaxTLSVersion = tls.VersionTLS13
+
+func fipsModule() (string, bool) {
+	return "Go Cryptographic Module", fips140.Enabled()
+}
@@ -0,0 +1,15 @@
+//go:build boringcrypto
+
+package checkhttp
+
+import (
+	"crypto/boring"
+	"crypto/tls"
+)
+
+// BoringCrypto only approves TLS 1.2
+const fipsMaxTLSVersion = tls.VersionTLS12
+
+func fipsModule() (string, bool) {
+	return "BoringCrypto", boring.Enabled()
+}
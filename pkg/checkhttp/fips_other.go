@@ -0,0 +1,11 @@
+//go:build !go1.24 && !boringcrypto
+
+package checkhttp
+
+import "crypto/tls"
+
+const fipsMaxTLSVersion = tls.VersionTLS12
+
+func fipsModule() (string, bool) {
+	return "", false
+}
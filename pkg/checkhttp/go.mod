@@ -5,6 +5,11 @@ go 1.21
 require (
 	github.com/dustin/go-humanize v1.0.1
 	github.com/sni/go-flags v0.0.0-20240724130408-1ec865bcf4f3
+	golang.org/x/crypto v0.25.0
+	golang.org/x/net v0.27.0
+	golang.org/x/text v0.16.0
 )
 
-require golang.org/x/sys v0.21.0 // indirect
+require golang.org/x/sys v0.22.0
+
+require software.sslmate.com/src/go-pkcs12 v0.7.3
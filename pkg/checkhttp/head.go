@@ -0,0 +1,53 @@
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// checkHeadConsistency requests the final url again with HEAD and compares
+// status, Content-Type and Content-Length with the GET response.
+func checkHeadConsistency(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request, res *http.Response, bodySize uint64) (string, *reqError) {
+	headOpts := opts
+	headOpts.Method = "HEAD"
+	headReq, err := redirectRequest(ctx, headOpts, req, req.URL, http.StatusSeeOther)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Error in building HEAD request: %v", err),
+			UNKNOWN,
+		}
+	}
+	headRes, err := client.Do(headReq)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Error in HEAD request: %v", err),
+			CRITICAL,
+		}
+	}
+	headRes.Body.Close()
+
+	var diffs []string
+	if headRes.StatusCode != res.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status %d != %d", headRes.StatusCode, res.StatusCode))
+	}
+	if headType, getType := headRes.Header.Get("Content-Type"), res.Header.Get("Content-Type"); headType != getType {
+		diffs = append(diffs, fmt.Sprintf("Content-Type %q != %q", headType, getType))
+	}
+	// HEAD responses may omit the Content-Length
+	getLength := res.ContentLength
+	if getLength < 0 && !opts.NoBody && !opts.Compressed {
+		getLength = int64(bodySize)
+	}
+	if headRes.ContentLength >= 0 && getLength >= 0 && headRes.ContentLength != getLength {
+		diffs = append(diffs, fmt.Sprintf("Content-Length %d != %d", headRes.ContentLength, getLength))
+	}
+	if len(diffs) > 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - HEAD response differs from GET (%s) from host on port %d", strings.Join(diffs, ", "), opts.Port),
+			CRITICAL,
+		}
+	}
+	return "HEAD consistent with GET", nil
+}
@@ -0,0 +1,188 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func formatDurationThreshold(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%f", d.Seconds())
+}
+
+// checkClockSkew compares the response Date header against the local time
+// the response was received at.
+func checkClockSkew(opts commandOpts, h http.Header, received time.Time) (string, *reqError) {
+	dateHeader := h.Get("Date")
+	if dateHeader == "" {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No Date header received from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	date, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid Date header %q received from host on port %d", dateHeader, opts.Port),
+			CRITICAL,
+		}
+	}
+
+	skew := date.Sub(received.Truncate(time.Second))
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+	if opts.MaxClockSkewCritical > 0 && abs > opts.MaxClockSkewCritical {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Clock skew of %s (> %s) detected from host on port %d", skew, opts.MaxClockSkewCritical, opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.MaxClockSkew > 0 && abs > opts.MaxClockSkew {
+		return "", &reqError{
+			fmt.Sprintf("HTTP WARNING - Clock skew of %s (> %s) detected from host on port %d", skew, opts.MaxClockSkew, opts.Port),
+			WARNING,
+		}
+	}
+
+	return fmt.Sprintf("clock_skew=%fs;%s;%s;;",
+		skew.Seconds(),
+		formatDurationThreshold(opts.MaxClockSkew),
+		formatDurationThreshold(opts.MaxClockSkewCritical)), nil
+}
+
+// checkCacheAge verifies the Age header set by caches and proxies. A missing
+// Age header means the response came from the origin and is considered fresh.
+func checkCacheAge(opts commandOpts, h http.Header) (string, *reqError) {
+	var age int64
+	if ageHeader := h.Get("Age"); ageHeader != "" {
+		var err error
+		age, err = strconv.ParseInt(strings.TrimSpace(ageHeader), 10, 64)
+		if err != nil || age < 0 {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Invalid Age header %q received from host on port %d", ageHeader, opts.Port),
+				CRITICAL,
+			}
+		}
+	}
+	if age > opts.MaxCacheAge {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Cached content is %d seconds old (> %d) from host on port %d", age, opts.MaxCacheAge, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("age=%ds;;%d;0;", age, opts.MaxCacheAge), nil
+}
+
+// checkTrailers verifies the expected "Name: value" trailers. Trailers are
+// only available after the body has been read completely.
+func checkTrailers(opts commandOpts, trailer http.Header) ([]string, *reqError) {
+	var matched []string
+	for _, expect := range opts.ExpectTrailer {
+		kv := strings.SplitN(expect, ":", 2)
+		name := strings.TrimSpace(kv[0])
+		values, ok := trailer[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return nil, &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Trailer %s not found in response from host on port %d", name, opts.Port),
+				CRITICAL,
+			}
+		}
+		if len(kv) == 1 {
+			matched = append(matched, fmt.Sprintf("Trailer %s found", name))
+			continue
+		}
+		want := strings.TrimSpace(kv[1])
+		found := false
+		for _, v := range values {
+			if strings.TrimSpace(v) == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Trailer %s: %q did not match %q from host on port %d", name, strings.Join(values, ", "), want, opts.Port),
+				CRITICAL,
+			}
+		}
+		matched = append(matched, fmt.Sprintf("Trailer %s matched %q", name, want))
+	}
+	return matched, nil
+}
+
+// checkFraming verifies how the response body was framed on the wire.
+func checkFraming(opts commandOpts, res *http.Response) (string, *reqError) {
+	chunked := len(res.TransferEncoding) > 0 && res.TransferEncoding[0] == "chunked"
+	framing := "Content-Length"
+	switch {
+	case chunked:
+		framing = "chunked transfer encoding"
+	case res.ContentLength < 0:
+		framing = "no Content-Length"
+	}
+	if res.Uncompressed {
+		framing += " (decompressed transparently)"
+	}
+
+	if opts.ExpectChunked && res.ProtoMajor >= 2 {
+		// HTTP/2 frames the body itself and has no chunked transfer encoding
+		framing += ", chunked check skipped for " + res.Proto
+	} else if opts.ExpectChunked && !chunked {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Response body was not chunked (%s, %s) from host on port %d", res.Proto, framing, opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.ExpectContentLength && (chunked || res.ContentLength < 0) {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Response has no Content-Length (%s, %s) from host on port %d", res.Proto, framing, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("Body framed by %s", framing), nil
+}
+
+// checkHeaderLimits verifies the size and number of response header lines.
+func checkHeaderLimits(opts commandOpts, h http.Header) ([]string, *reqError) {
+	var size uint64
+	count := 0
+	for name, values := range h {
+		for _, v := range values {
+			size += uint64(len(name) + len(": ") + len(v) + len("\r\n"))
+			count++
+		}
+	}
+
+	if opts.maxHeaderBytes > 0 && size > opts.maxHeaderBytes {
+		return nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Response headers are %d bytes (> %d) from host on port %d", size, opts.maxHeaderBytes, opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.MaxHeaderCount > 0 && count > opts.MaxHeaderCount {
+		return nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Response has %d header lines (> %d) from host on port %d", count, opts.MaxHeaderCount, opts.Port),
+			CRITICAL,
+		}
+	}
+
+	headerSizeCrit := ""
+	if opts.maxHeaderBytes > 0 {
+		headerSizeCrit = strconv.FormatUint(opts.maxHeaderBytes, 10)
+	}
+	headerCountCrit := ""
+	if opts.MaxHeaderCount > 0 {
+		headerCountCrit = strconv.Itoa(opts.MaxHeaderCount)
+	}
+	return []string{
+		fmt.Sprintf("header_size=%dB;;%s;0;", size, headerSizeCrit),
+		fmt.Sprintf("header_count=%d;;%s;0;", count, headerCountCrit),
+	}, nil
+}
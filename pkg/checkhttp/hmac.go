@@ -0,0 +1,112 @@
+package checkhttp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// hmacSigner describes the --hmac-sign signature header.
+type hmacSigner struct {
+	header   string
+	hash     func() hash.Hash
+	secret   []byte
+	encoding string
+	prefix   string
+}
+
+// parseHMACSign parses `header=NAME,algo=sha256,secret-file=FILE` with the
+// optional keys encoding=hex|base64 and prefix=STRING.
+func parseHMACSign(s string) (*hmacSigner, error) {
+	signer := &hmacSigner{hash: sha256.New, encoding: "hex"}
+	secretFile := ""
+	for _, part := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter %q, expected name=value", part)
+		}
+		switch strings.TrimSpace(k) {
+		case "header":
+			signer.header = v
+		case "algo":
+			switch strings.ToLower(v) {
+			case "sha1":
+				signer.hash = sha1.New
+			case "sha256":
+				signer.hash = sha256.New
+			case "sha512":
+				signer.hash = sha512.New
+			default:
+				return nil, fmt.Errorf("unsupported algo %q, expected sha1, sha256 or sha512", v)
+			}
+		case "secret-file":
+			secretFile = v
+		case "encoding":
+			if v != "hex" && v != "base64" {
+				return nil, fmt.Errorf("unsupported encoding %q, expected hex or base64", v)
+			}
+			signer.encoding = v
+		case "prefix":
+			signer.prefix = v
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", k)
+		}
+	}
+	if signer.header == "" {
+		return nil, fmt.Errorf("header is required")
+	}
+	if secretFile == "" {
+		return nil, fmt.Errorf("secret-file is required")
+	}
+	secret, err := os.ReadFile(secretFile)
+	if err != nil {
+		return nil, err
+	}
+	signer.secret = []byte(strings.TrimRight(string(secret), "\r\n"))
+	return signer, nil
+}
+
+// sign returns the header value for the request, the HMAC covers the
+// method, the request uri and the body separated by newlines.
+func (s *hmacSigner) sign(method, uri string, body []byte) string {
+	mac := hmac.New(s.hash, s.secret)
+	mac.Write([]byte(method + "\n" + uri + "\n"))
+	mac.Write(body)
+	sum := mac.Sum(nil)
+	if s.encoding == "base64" {
+		return s.prefix + base64.StdEncoding.EncodeToString(sum)
+	}
+	return s.prefix + hex.EncodeToString(sum)
+}
+
+// hmacTransport adds the HMAC signature header to every request.
+type hmacTransport struct {
+	next   http.RoundTripper
+	signer *hmacSigner
+}
+
+func (t *hmacTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	payload, err := requestPayload(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read body for signing: %v", err)
+	}
+	req.Header.Set(t.signer.header, t.signer.sign(req.Method, req.URL.RequestURI(), payload))
+	return t.next.RoundTrip(req)
+}
+
+// CloseIdleConnections passes through to the wrapped transport, so
+// http.Client.CloseIdleConnections reaches the connection pool.
+func (t *hmacTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
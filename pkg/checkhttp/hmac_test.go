@@ -0,0 +1,103 @@
+package checkhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHMACSign(t *testing.T) {
+	secretFile := writeTestFile(t, "secret", "s3cret\n")
+	body := []byte(`{"a":1}`)
+	tests := []struct {
+		spec   string
+		method string
+		uri    string
+		body   []byte
+		want   string
+	}{
+		{
+			spec:   "header=X-Signature,algo=sha256,secret-file=" + secretFile,
+			method: "POST", uri: "/api/v1/items?x=1", body: body,
+			want: "3328793287f266e244f092d0ea649a69a33b027f72ed14e0d3b2ba9a106e6b82",
+		},
+		{
+			spec:   "header=X-Signature,algo=sha1,secret-file=" + secretFile,
+			method: "POST", uri: "/api/v1/items?x=1", body: body,
+			want: "0010973b752a2e2afd50d0c7f3f40e7e4dd4f696",
+		},
+		{
+			spec:   "header=X-Signature,algo=SHA512,secret-file=" + secretFile,
+			method: "POST", uri: "/api/v1/items?x=1", body: body,
+			want: "cbb5cee29eb88826f18b46e34001d3487ccce8f73ff9539272ed6a2cb9bf55eccf170b9e9cccfe31c97b4c6366d68a77f1ba7626d07e2a00a523bd3bb2bc49ea",
+		},
+		{
+			spec:   "header=X-Signature,algo=sha256,secret-file=" + secretFile + ",encoding=base64,prefix=sha256=",
+			method: "POST", uri: "/api/v1/items?x=1", body: body,
+			want: "sha256=Myh5MofyZuJE8JLQ6mSaaaM7An9y7RTg07K6mhBua4I=",
+		},
+		{
+			spec:   "header=X-Signature,secret-file=" + secretFile,
+			method: "GET", uri: "/",
+			want: "3cf2a13dab5d7cf2da24daaf02640b936dcd032998b0c860357e4638039a8fc5",
+		},
+	}
+	for _, tt := range tests {
+		signer, err := parseHMACSign(tt.spec)
+		if err != nil {
+			t.Errorf("parseHMACSign(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := signer.sign(tt.method, tt.uri, tt.body); got != tt.want {
+			t.Errorf("sign with %q = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseHMACSignErrors(t *testing.T) {
+	secretFile := writeTestFile(t, "secret", "s3cret")
+	for _, spec := range []string{
+		"algo=sha256,secret-file=" + secretFile,
+		"header=X-Signature",
+		"header=X-Signature,algo=md5,secret-file=" + secretFile,
+		"header=X-Signature,encoding=base32,secret-file=" + secretFile,
+		"header=X-Signature,secret-file=" + secretFile + ",unknown=1",
+		"header=X-Signature,secret-file=" + secretFile + ",novalue",
+		"header=X-Signature,secret-file=" + secretFile + ".missing",
+	} {
+		if _, err := parseHMACSign(spec); err == nil {
+			t.Errorf("parseHMACSign(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestHMACTransport(t *testing.T) {
+	secretFile := writeTestFile(t, "secret", "s3cret")
+	signer, err := parseHMACSign("header=X-Signature,secret-file=" + secretFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotSignature, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-Signature")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &hmacTransport{http.DefaultTransport, signer}}
+	res, err := client.Post(srv.URL+"/api/v1/items?x=1", "application/json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	want := "3328793287f266e244f092d0ea649a69a33b027f72ed14e0d3b2ba9a106e6b82"
+	if gotSignature != want {
+		t.Errorf("signature = %q, want %q", gotSignature, want)
+	}
+	if gotBody != `{"a":1}` {
+		t.Errorf("body = %q, the signed body must still be sent", gotBody)
+	}
+}
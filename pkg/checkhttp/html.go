@@ -0,0 +1,240 @@
+package checkhttp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// metaRefreshSniffLen limits how much of a page is inspected for a meta
+// refresh tag, which has to appear in the document head.
+const metaRefreshSniffLen = 64 * 1024
+
+// findMetaRefresh returns the target URL of a
+// `<meta http-equiv="refresh" content="0; url=...">` tag.
+func findMetaRefresh(page []byte) (string, bool) {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return "", false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "body" {
+				return "", false
+			}
+			if string(name) != "meta" || !hasAttr {
+				continue
+			}
+			attrs := tagAttributes(z)
+			if !strings.EqualFold(attrs["http-equiv"], "refresh") {
+				continue
+			}
+			if target := parseRefreshContent(attrs["content"]); target != "" {
+				return target, true
+			}
+		}
+	}
+}
+
+func tagAttributes(z *html.Tokenizer) map[string]string {
+	attrs := map[string]string{}
+	for {
+		key, val, more := z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// parseRefreshContent extracts the url from a refresh value like
+// `5; URL='/next'`.
+func parseRefreshContent(content string) string {
+	_, target, ok := strings.Cut(content, ";")
+	if !ok {
+		_, target, ok = strings.Cut(content, ",")
+		if !ok {
+			return ""
+		}
+	}
+	target = strings.TrimSpace(target)
+	if len(target) >= 4 && strings.EqualFold(target[:3], "url") {
+		target = strings.TrimSpace(target[3:])
+		if !strings.HasPrefix(target, "=") {
+			return ""
+		}
+		target = strings.TrimSpace(target[1:])
+	}
+	return strings.Trim(target, `'"`)
+}
+
+// findCanonical returns the href of a `<link rel="canonical">` tag.
+func findCanonical(page []byte) (string, bool) {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return "", false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "link" || !hasAttr {
+				continue
+			}
+			attrs := tagAttributes(z)
+			for _, rel := range strings.Fields(attrs["rel"]) {
+				if strings.EqualFold(rel, "canonical") {
+					return strings.TrimSpace(attrs["href"]), true
+				}
+			}
+		}
+	}
+}
+
+// findHTMLLang returns the lang attribute of the `<html>` element.
+func findHTMLLang(page []byte) (string, bool) {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return "", false
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "html" {
+				continue
+			}
+			if !hasAttr {
+				return "", false
+			}
+			lang, ok := tagAttributes(z)["lang"]
+			return strings.TrimSpace(lang), ok && lang != ""
+		}
+	}
+}
+
+// subresourceAttrs lists the attributes loading subresources per element.
+var subresourceAttrs = map[string][]string{
+	"script": {"src"},
+	"img":    {"src", "srcset"},
+	"link":   {"href"},
+	"iframe": {"src"},
+	"source": {"src", "srcset"},
+	"video":  {"src", "poster"},
+	"audio":  {"src"},
+	"track":  {"src"},
+	"embed":  {"src"},
+	"object": {"data"},
+}
+
+// findMixedContent returns all plain http:// subresource urls of a page.
+func findMixedContent(page []byte) []string {
+	var insecure []string
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return insecure
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrNames, ok := subresourceAttrs[string(name)]
+			if !ok || !hasAttr {
+				continue
+			}
+			attrs := tagAttributes(z)
+			if string(name) == "link" && !isSubresourceLink(attrs["rel"]) {
+				continue
+			}
+			for _, attr := range attrNames {
+				for _, u := range strings.Split(attrs[attr], ",") {
+					fields := strings.Fields(u)
+					if len(fields) > 0 && strings.HasPrefix(strings.ToLower(fields[0]), "http://") {
+						insecure = append(insecure, fields[0])
+					}
+				}
+			}
+		}
+	}
+}
+
+// extractLinks returns all hyperlinks and subresource urls of a page.
+func extractLinks(page []byte) []string {
+	var links []string
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !hasAttr {
+				continue
+			}
+			attrNames := subresourceAttrs[string(name)]
+			switch string(name) {
+			case "a", "area":
+				attrNames = []string{"href"}
+			case "link":
+				attrNames = []string{"href"}
+			}
+			if len(attrNames) == 0 {
+				continue
+			}
+			attrs := tagAttributes(z)
+			for _, attr := range attrNames {
+				val := attrs[attr]
+				if attr == "srcset" {
+					for _, candidate := range strings.Split(val, ",") {
+						if fields := strings.Fields(candidate); len(fields) > 0 {
+							links = append(links, fields[0])
+						}
+					}
+					continue
+				}
+				if val = strings.TrimSpace(val); val != "" {
+					links = append(links, val)
+				}
+			}
+		}
+	}
+}
+
+// isSubresourceLink returns true for link relations which load resources
+// into the page.
+func isSubresourceLink(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		switch r {
+		case "stylesheet", "icon", "preload", "modulepreload", "manifest":
+			return true
+		}
+	}
+	return false
+}
+
+// findIcon returns the href of the first `<link rel="icon">` tag.
+func findIcon(page []byte) (string, bool) {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return "", false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "link" || !hasAttr {
+				continue
+			}
+			attrs := tagAttributes(z)
+			for _, rel := range strings.Fields(attrs["rel"]) {
+				if strings.EqualFold(rel, "icon") && strings.TrimSpace(attrs["href"]) != "" {
+					return strings.TrimSpace(attrs["href"]), true
+				}
+			}
+		}
+	}
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,38 @@
+package checkhttp
+
+import (
+	"net"
+
+	"golang.org/x/net/idna"
+)
+
+// isASCII returns true if s contains only ascii characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// punycodeHost converts the internationalized host name of a host[:port]
+// value to its ascii form, e.g. `bücher.example` becomes
+// `xn--bcher-kva.example`.
+func punycodeHost(hostname string) (string, error) {
+	if isASCII(hostname) {
+		return hostname, nil
+	}
+	host, port, err := net.SplitHostPort(hostname)
+	if err != nil {
+		host, port = hostname, ""
+	}
+	host, err = idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", err
+	}
+	if port == "" {
+		return host, nil
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,169 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// integrityAlgorithms lists the supported subresource integrity hashes
+// ordered by strength.
+var integrityAlgorithms = []string{"sha256", "sha384", "sha512"}
+
+type integrityRule struct {
+	URL    string
+	Alg    string
+	Digest []byte
+}
+
+func newIntegrityHash(alg string) hash.Hash {
+	switch alg {
+	case "sha256":
+		return sha256.New()
+	case "sha384":
+		return sha512.New384()
+	default:
+		return sha512.New()
+	}
+}
+
+// parseIntegrity parses `sha384-BASE64` for the checked response or
+// `URL=sha384-BASE64` for another asset.
+func parseIntegrity(value string) (integrityRule, error) {
+	rule := integrityRule{}
+	metadata := value
+	for _, alg := range integrityAlgorithms {
+		if i := strings.LastIndex(value, "="+alg+"-"); i > 0 {
+			rule.URL = value[:i]
+			metadata = value[i+1:]
+			break
+		}
+	}
+	alg, digest, ok := strings.Cut(metadata, "-")
+	if !ok {
+		return rule, fmt.Errorf("invalid integrity %q, expected sha384-BASE64", value)
+	}
+	rule.Alg = alg
+	switch alg {
+	case "sha256", "sha384", "sha512":
+	default:
+		return rule, fmt.Errorf("unsupported integrity algorithm %q", alg)
+	}
+	// options like `?ct=...` are allowed by the spec but ignored
+	digest, _, _ = strings.Cut(digest, "?")
+	decoded, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return rule, fmt.Errorf("invalid integrity digest %q: %v", digest, err)
+	}
+	if len(decoded) != newIntegrityHash(alg).Size() {
+		return rule, fmt.Errorf("invalid %s digest length in %q", alg, value)
+	}
+	rule.Digest = decoded
+	return rule, nil
+}
+
+// integrityHashes creates one hash per algorithm used by rules.
+func integrityHashes(rules []integrityRule) map[string]hash.Hash {
+	hashes := map[string]hash.Hash{}
+	for _, r := range rules {
+		if _, ok := hashes[r.Alg]; !ok {
+			hashes[r.Alg] = newIntegrityHash(r.Alg)
+		}
+	}
+	return hashes
+}
+
+// verifyIntegrity follows the SRI rules: only the strongest algorithm
+// counts and any of its digests may match.
+func verifyIntegrity(rules []integrityRule, hashes map[string]hash.Hash) bool {
+	strongest := ""
+	for _, alg := range integrityAlgorithms {
+		if _, ok := hashes[alg]; ok {
+			strongest = alg
+		}
+	}
+	sum := hashes[strongest].Sum(nil)
+	for _, r := range rules {
+		if r.Alg == strongest && subtle.ConstantTimeCompare(r.Digest, sum) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func hashWriters(hashes map[string]hash.Hash) []io.Writer {
+	var writers []io.Writer
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	return writers
+}
+
+// checkAssetIntegrity fetches all assets with integrity rules and verifies
+// their digests while streaming the body.
+func checkAssetIntegrity(ctx context.Context, client *http.Client, opts commandOpts, from *http.Request) (string, *reqError) {
+	byURL := map[string][]integrityRule{}
+	var urls []string
+	for _, r := range opts.integrity {
+		if r.URL == "" {
+			continue
+		}
+		if _, ok := byURL[r.URL]; !ok {
+			urls = append(urls, r.URL)
+		}
+		byURL[r.URL] = append(byURL[r.URL], r)
+	}
+
+	for _, asset := range urls {
+		u, err := from.URL.Parse(asset)
+		if err != nil {
+			return "", &reqError{
+				fmt.Sprintf("HTTP UNKNOWN - Invalid integrity url %q: %v", asset, err),
+				UNKNOWN,
+			}
+		}
+		req, err := redirectRequest(ctx, opts, from, u, http.StatusSeeOther)
+		if err != nil {
+			return "", &reqError{
+				fmt.Sprintf("HTTP UNKNOWN - Error in building request: %v", err),
+				UNKNOWN,
+			}
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Asset %s: %v", u, err),
+				CRITICAL,
+			}
+		}
+		hashes := integrityHashes(byURL[asset])
+		_, err = io.Copy(io.MultiWriter(hashWriters(hashes)...), res.Body)
+		res.Body.Close()
+		if err != nil {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Asset %s: %v", u, err),
+				CRITICAL,
+			}
+		}
+		if res.StatusCode != http.StatusOK {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Asset %s: %s", u, res.Status),
+				CRITICAL,
+			}
+		}
+		if !verifyIntegrity(byURL[asset], hashes) {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Asset %s does not match its integrity digest", u),
+				CRITICAL,
+			}
+		}
+	}
+	return fmt.Sprintf("%d assets matched integrity", len(urls)), nil
+}
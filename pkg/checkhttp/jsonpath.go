@@ -0,0 +1,133 @@
+package checkhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathLookup resolves a simple JSONPath expression like
+// `$.items[0].name` or `$['some key']` against a decoded JSON document.
+func jsonPathLookup(doc interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path must start with $")
+	}
+	cur := doc
+	rest := path[1:]
+	for rest != "" {
+		var key string
+		index := -1
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key = rest[:end]
+			rest = rest[end:]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in json path %q", path)
+			}
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in json path %q", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				key = selector[1 : len(selector)-1]
+			} else {
+				i, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in json path %q", selector, path)
+				}
+				index = i
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in json path %q", rest[0], path)
+		}
+
+		if index >= 0 {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an array", path)
+			}
+			if index >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range in %s", index, path)
+			}
+			cur = arr[index]
+			continue
+		}
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %q is not an object key", path, key)
+		}
+		cur, ok = obj[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: key %q not found", path, key)
+		}
+	}
+	return cur, nil
+}
+
+// parseJSONBody decodes a buffered response body.
+func parseJSONBody(body []byte) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// checkJSONLength verifies the length of the array (or object) at the
+// configured json path of the buffered body.
+func checkJSONLength(opts commandOpts, b *capWriter) (string, *reqError) {
+	if b.Discarded() > 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Response body exceeds max-buffer-size %s, cannot parse JSON", opts.MaxBufferSize),
+			UNKNOWN,
+		}
+	}
+	doc, err := parseJSONBody(b.Bytes())
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid JSON in response from host on port %d: %v", opts.Port, err),
+			CRITICAL,
+		}
+	}
+	value, err := jsonPathLookup(doc, opts.JSONLength)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %v in response from host on port %d", err, opts.Port),
+			CRITICAL,
+		}
+	}
+	var length int
+	switch v := value.(type) {
+	case []interface{}:
+		length = len(v)
+	case map[string]interface{}:
+		length = len(v)
+	default:
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %s is not an array in response from host on port %d", opts.JSONLength, opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.JSONLengthMin >= 0 && length < opts.JSONLengthMin {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %s has %d elements (< %d) from host on port %d", opts.JSONLength, length, opts.JSONLengthMin, opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.JSONLengthMax >= 0 && length > opts.JSONLengthMax {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %s has %d elements (> %d) from host on port %d", opts.JSONLength, length, opts.JSONLengthMax, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("%s has %d elements", opts.JSONLength, length), nil
+}
@@ -0,0 +1,57 @@
+package checkhttp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONPathLookup(t *testing.T) {
+	doc, err := parseJSONBody([]byte(`{
+		"items": [{"name": "a", "tags": ["x", "y"]}, {"name": "b"}],
+		"some key": {"nested": 1},
+		"count": 2
+	}`))
+	if err != nil {
+		t.Fatalf("parseJSONBody: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "$", want: doc},
+		{path: "$.count", want: float64(2)},
+		{path: "$.items[0].name", want: "a"},
+		{path: "$.items[1].name", want: "b"},
+		{path: "$.items[0].tags[1]", want: "y"},
+		{path: "$['some key'].nested", want: float64(1)},
+		{path: `$["some key"]["nested"]`, want: float64(1)},
+		{path: "$.items[0].tags", want: []interface{}{"x", "y"}},
+		{path: "items", wantErr: true},
+		{path: "$.missing", wantErr: true},
+		{path: "$.items[2]", wantErr: true},
+		{path: "$.items[x]", wantErr: true},
+		{path: "$.items[0", wantErr: true},
+		{path: "$.count[0]", wantErr: true},
+		{path: "$.items.name", wantErr: true},
+		{path: "$..count", wantErr: true},
+		{path: "$count", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := jsonPathLookup(doc, tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("jsonPathLookup(%q) = %v, want error", tt.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("jsonPathLookup(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("jsonPathLookup(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package checkhttp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const tcpKeepAliveTuningSupported = false
+
+func setTCPKeepAlive(_ net.Conn, _ time.Duration, _ int) error {
+	return fmt.Errorf("tcp keepalive tuning is not supported on this platform")
+}
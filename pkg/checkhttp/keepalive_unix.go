@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+package checkhttp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const tcpKeepAliveTuningSupported = true
+
+// setTCPKeepAlive sets the keepalive probe interval and count of conn. It must
+// be called after dialing, because the dialer resets the interval to the idle time.
+func setTCPKeepAlive(conn net.Conn, interval time.Duration, count int) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("connection does not expose a socket")
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		if interval > 0 {
+			secs := int((interval + time.Second - 1) / time.Second)
+			if sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, secs); sockErr != nil {
+				return
+			}
+		}
+		if count > 0 {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, count)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
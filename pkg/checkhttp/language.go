@@ -0,0 +1,43 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// languageMatches reports whether the language tag matches the expected
+// language, which may be a prefix like "de" for "de-DE".
+func languageMatches(tag, expected string) bool {
+	tag = strings.TrimSpace(tag)
+	return strings.EqualFold(tag, expected) ||
+		(len(tag) > len(expected) && strings.EqualFold(tag[:len(expected)], expected) && tag[len(expected)] == '-')
+}
+
+// checkContentLanguage verifies the Content-Language header or, when it is
+// missing, the lang attribute of the html element.
+func checkContentLanguage(opts commandOpts, h http.Header, page []byte) (string, *reqError) {
+	source := "Content-Language"
+	var tags []string
+	if v := h.Get("Content-Language"); v != "" {
+		tags = strings.Split(v, ",")
+	} else if lang, ok := findHTMLLang(page); ok {
+		source = "html lang"
+		tags = []string{lang}
+	}
+	if len(tags) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No Content-Language received (expected %s) from host on port %d", opts.ExpectLanguage, opts.Port),
+			CRITICAL,
+		}
+	}
+	for _, tag := range tags {
+		if languageMatches(tag, opts.ExpectLanguage) {
+			return fmt.Sprintf("%s %s matched", source, strings.TrimSpace(tag)), nil
+		}
+	}
+	return "", &reqError{
+		fmt.Sprintf("HTTP CRITICAL - %s %q does not match %s from host on port %d", source, strings.Join(tags, ","), opts.ExpectLanguage, opts.Port),
+		CRITICAL,
+	}
+}
@@ -0,0 +1,155 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// link is a single RFC 8288 web link.
+type link struct {
+	Target string
+	Params map[string]string
+}
+
+// parseLinkHeaders parses all Link headers like
+// `<https://example.com/?page=2>; rel="next", </style.css>; rel=preload; as=style`.
+func parseLinkHeaders(h http.Header) []link {
+	var links []link
+	for _, v := range h.Values("Link") {
+		rest := v
+		for {
+			start := strings.IndexByte(rest, '<')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(rest[start:], '>')
+			if end < 0 {
+				break
+			}
+			l := link{
+				Target: strings.TrimSpace(rest[start+1 : start+end]),
+				Params: map[string]string{},
+			}
+			rest = rest[start+end+1:]
+
+			// params run until the next comma outside of quotes
+			params := rest
+			quoted := false
+			for i, c := range rest {
+				if c == '"' {
+					quoted = !quoted
+				}
+				if c == ',' && !quoted {
+					params = rest[:i]
+					break
+				}
+			}
+			rest = rest[len(params):]
+			for _, p := range splitQuoted(params, ';') {
+				key, val, _ := strings.Cut(p, "=")
+				key = strings.ToLower(strings.TrimSpace(key))
+				if key == "" {
+					continue
+				}
+				if _, exists := l.Params[key]; exists {
+					// only the first occurrence counts
+					continue
+				}
+				l.Params[key] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// matches returns true if the link satisfies all `key=value` conditions of
+// expr, which are separated by semicolons. The key href compares the link
+// target, rel matches any of the space separated relation types.
+func (l link) matches(expr string) bool {
+	for _, cond := range strings.Split(expr, ";") {
+		key, want, _ := strings.Cut(cond, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		want = strings.Trim(strings.TrimSpace(want), `"`)
+		switch key {
+		case "":
+			continue
+		case "href":
+			if l.Target != want {
+				return false
+			}
+		case "rel":
+			found := false
+			for _, rel := range strings.Fields(l.Params["rel"]) {
+				if strings.EqualFold(rel, want) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		default:
+			val, ok := l.Params[key]
+			if !ok || (want != "" && val != want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkLinks(opts commandOpts, h http.Header) ([]string, *reqError) {
+	links := parseLinkHeaders(h)
+	var matched []string
+	for _, expr := range opts.ExpectLink {
+		found := ""
+		for _, l := range links {
+			if l.matches(expr) {
+				found = l.Target
+				break
+			}
+		}
+		if found == "" {
+			return nil, &reqError{
+				fmt.Sprintf("HTTP CRITICAL - No Link header matched %q from host on port %d", expr, opts.Port),
+				CRITICAL,
+			}
+		}
+		matched = append(matched, fmt.Sprintf("Link %s matched <%s>", expr, found))
+	}
+	return matched, nil
+}
+
+// checkCanonical compares the canonical URL declared in the page (or in a
+// Link header) with the expected one.
+func checkCanonical(opts commandOpts, res *http.Response, page []byte) (string, *reqError) {
+	canonical, ok := findCanonical(page)
+	source := "link tag"
+	if !ok {
+		for _, l := range parseLinkHeaders(res.Header) {
+			if l.matches("rel=canonical") {
+				canonical, ok = l.Target, true
+				source = "Link header"
+				break
+			}
+		}
+	}
+	if !ok {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - No canonical URL found in response from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	if u, err := res.Request.URL.Parse(canonical); err == nil {
+		canonical = u.String()
+	}
+	if canonical != opts.ExpectCanonical {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Canonical URL %s (from %s) did not match %s from host on port %d", canonical, source, opts.ExpectCanonical, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("Canonical URL matched %s", canonical), nil
+}
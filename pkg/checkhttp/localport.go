@@ -0,0 +1,53 @@
+package checkhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// parseLocalPort parses a port or port range like 40000-40100.
+func parseLocalPort(value string) (minPort, maxPort int, err error) {
+	first, last, isRange := strings.Cut(value, "-")
+	minPort, err = strconv.Atoi(strings.TrimSpace(first))
+	if err != nil || minPort < 1 || minPort > 65535 {
+		return 0, 0, fmt.Errorf("invalid local port %q", first)
+	}
+	maxPort = minPort
+	if isRange {
+		maxPort, err = strconv.Atoi(strings.TrimSpace(last))
+		if err != nil || maxPort < 1 || maxPort > 65535 {
+			return 0, 0, fmt.Errorf("invalid local port %q", last)
+		}
+		if maxPort < minPort {
+			return 0, 0, fmt.Errorf("local port range %d-%d is empty", minPort, maxPort)
+		}
+	}
+	return minPort, maxPort, nil
+}
+
+// dialLocalPort dials addr from a source port within minPort-maxPort. Ports
+// are tried starting at a random offset until one is available.
+func dialLocalPort(ctx context.Context, dialer *net.Dialer, network, addr string, minPort, maxPort int) (net.Conn, error) {
+	count := maxPort - minPort + 1
+	offset := rand.Intn(count)
+	var err error
+	for i := 0; i < count; i++ {
+		d := *dialer
+		d.LocalAddr = &net.TCPAddr{Port: minPort + (offset+i)%count}
+		var conn net.Conn
+		conn, err = d.DialContext(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) && !errors.Is(err, syscall.EADDRNOTAVAIL) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no local port available in range %d-%d: %v", minPort, maxPort, err)
+}
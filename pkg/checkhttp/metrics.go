@@ -0,0 +1,86 @@
+package checkhttp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var metricNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// metricPath returns the dotted metric prefix for the checked host, e.g.
+// check_http.www_example_com.
+func metricPath(opts commandOpts) string {
+	host := strings.Trim(metricNameUnsafe.ReplaceAllString(opts.Hostname, "_"), "_")
+	if opts.MetricPrefix == "" {
+		return host
+	}
+	return strings.TrimSuffix(opts.MetricPrefix, ".") + "." + host
+}
+
+type metric struct {
+	name  string
+	value float64
+	// timing metrics are sent as statsd timers in milliseconds
+	timing bool
+}
+
+func resultMetrics(result *Result, state int) []metric {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return []metric{
+		{"state", float64(state), false},
+		{"status", float64(result.StatusCode), false},
+		{"size", float64(result.Size), false},
+		{"time", ms(result.Duration), true},
+		{"dns", ms(result.Phases.DNS), true},
+		{"connect", ms(result.Phases.Connect), true},
+		{"tls", ms(result.Phases.TLS), true},
+		{"first_byte", ms(result.Phases.FirstByte), true},
+	}
+}
+
+// sendStatsd fires the result metrics as statsd timers and gauges over udp.
+func sendStatsd(opts commandOpts, result *Result, state int) error {
+	var buf bytes.Buffer
+	prefix := metricPath(opts)
+	for _, m := range resultMetrics(result, state) {
+		if m.timing {
+			fmt.Fprintf(&buf, "%s.%s:%.3f|ms\n", prefix, m.name, m.value)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s.%s:%g|g\n", prefix, m.name, m.value)
+	}
+	conn, err := net.DialTimeout("udp", opts.StatsdAddr, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// sendGraphite sends the result metrics using the graphite plaintext
+// protocol, timings in seconds.
+func sendGraphite(opts commandOpts, result *Result, state int) error {
+	var buf bytes.Buffer
+	prefix := metricPath(opts)
+	now := time.Now().Unix()
+	for _, m := range resultMetrics(result, state) {
+		if m.timing {
+			fmt.Fprintf(&buf, "%s.%s %.6f %d\n", prefix, m.name, m.value/1000, now)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s.%s %g %d\n", prefix, m.name, m.value, now)
+	}
+	conn, err := net.DialTimeout("tcp", opts.GraphiteAddr, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(opts.Timeout))
+	_, err = conn.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,73 @@
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const netnsSupported = true
+
+// netnsPath returns the path of a named network namespace as created by `ip netns add`.
+func netnsPath(name string) string {
+	if strings.ContainsRune(name, '/') {
+		return name
+	}
+	return filepath.Join("/var/run/netns", name)
+}
+
+// netnsDial wraps dial so sockets are created inside the given network
+// namespace. Name resolution still happens in the current namespace.
+func netnsDial(name string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	path := netnsPath(name)
+	// fail early on unknown namespaces, every dial opens it again
+	ns, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open network namespace: %v", err)
+	}
+	ns.Close()
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		type result struct {
+			conn net.Conn
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			// the thread is not unlocked when switching back fails, so the
+			// runtime discards it instead of reusing it in the wrong namespace.
+			runtime.LockOSThread()
+			orig, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", unix.Gettid()))
+			if err != nil {
+				done <- result{nil, fmt.Errorf("could not open current network namespace: %v", err)}
+				return
+			}
+			defer orig.Close()
+			ns, err := os.Open(path)
+			if err != nil {
+				runtime.UnlockOSThread()
+				done <- result{nil, fmt.Errorf("could not open network namespace: %v", err)}
+				return
+			}
+			err = unix.Setns(int(ns.Fd()), unix.CLONE_NEWNET)
+			ns.Close()
+			if err != nil {
+				runtime.UnlockOSThread()
+				done <- result{nil, fmt.Errorf("could not enter network namespace %s: %v", path, err)}
+				return
+			}
+			conn, err := dial(ctx, network, addr)
+			if err := unix.Setns(int(orig.Fd()), unix.CLONE_NEWNET); err == nil {
+				runtime.UnlockOSThread()
+			}
+			done <- result{conn, err}
+		}()
+		res := <-done
+		return res.conn, res.err
+	}, nil
+}
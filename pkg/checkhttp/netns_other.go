@@ -0,0 +1,15 @@
+//go:build !linux
+
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+const netnsSupported = false
+
+func netnsDial(_ string, _ func(ctx context.Context, network, addr string) (net.Conn, error)) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	return nil, fmt.Errorf("network namespaces are only supported on Linux")
+}
@@ -0,0 +1,93 @@
+package checkhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2Source fetches client credentials access tokens and caches them
+// until shortly before they expire, so repeated requests of --wait-for,
+// --consecutive or --benchmark reuse the token.
+type oauth2Source struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	authStyle    string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// accessToken returns the cached token or fetches a new one.
+func (s *oauth2Source) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expires.IsZero() || time.Now().Before(s.expires)) {
+		return s.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	if s.authStyle == "post" {
+		form.Set("client_id", s.clientID)
+		form.Set("client_secret", s.clientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.authStyle != "post" {
+		req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return "", err
+	}
+	var token oauth2TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("token endpoint returned %s: %v", res.Status, err)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s %s", res.Status, token.Error, token.Description)
+	}
+	if res.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned %s without access token", res.Status)
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+	s.token = token.AccessToken
+	s.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		// refresh a bit early so the token does not expire in flight
+		s.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - 10*time.Second)
+	}
+	return s.token, nil
+}
@@ -0,0 +1,52 @@
+package checkhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// checkOCSPStaple verifies the server stapled a good OCSP response for its
+// certificate.
+func checkOCSPStaple(opts commandOpts, state *tls.ConnectionState) (string, *reqError) {
+	failState := stateByName[opts.OCSPStapleState]
+	fail := func(format string, args ...interface{}) *reqError {
+		return &reqError{
+			fmt.Sprintf("HTTP %s - %s from host on port %d", stateNames[failState], fmt.Sprintf(format, args...), opts.Port),
+			failState,
+		}
+	}
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", fail("No server certificate received")
+	}
+	if len(state.OCSPResponse) == 0 {
+		return "", fail("No OCSP response stapled")
+	}
+	// only an issuer from a verified chain proves who signed the response
+	issuer := verifiedIssuers(opts, state)[0]
+	if issuer == nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Cannot verify OCSP response without issuer from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	res, err := ocsp.ParseResponseForCert(state.OCSPResponse, state.PeerCertificates[0], issuer)
+	if err != nil {
+		return "", fail("Invalid stapled OCSP response: %v", err)
+	}
+	switch res.Status {
+	case ocsp.Revoked:
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Certificate revoked on %s according to stapled OCSP response from host on port %d", res.RevokedAt.UTC().Format("2006-01-02 15:04 MST"), opts.Port),
+			CRITICAL,
+		}
+	case ocsp.Unknown:
+		return "", fail("Stapled OCSP response has status unknown")
+	}
+	if !res.NextUpdate.IsZero() && res.NextUpdate.Before(time.Now()) {
+		return "", fail("Stapled OCSP response expired on %s", res.NextUpdate.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	return "OCSP staple good", nil
+}
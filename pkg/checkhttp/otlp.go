@@ -0,0 +1,228 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP span kinds and status codes.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+type span struct {
+	parent int
+	name   string
+	kind   int
+	start  time.Time
+	end    time.Time
+	attrs  map[string]string
+	err    string
+}
+
+// spanRecorder collects the spans of a check run, the first span is the
+// root span covering the whole check.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []span
+}
+
+func newSpanRecorder() *spanRecorder {
+	r := &spanRecorder{}
+	r.begin(-1, "check_http", otlpSpanKindInternal, time.Now())
+	return r
+}
+
+// begin starts a span and returns its index, used as parent and for end.
+func (r *spanRecorder) begin(parent int, name string, kind int, start time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span{parent: parent, name: name, kind: kind, start: start, attrs: map[string]string{}})
+	return len(r.spans) - 1
+}
+
+// finish ends the span, err marks it failed unless empty.
+func (r *spanRecorder) finish(i int, err string, attrs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans[i].end = time.Now()
+	r.spans[i].err = err
+	for j := 0; j+1 < len(attrs); j += 2 {
+		r.spans[i].attrs[attrs[j]] = attrs[j+1]
+	}
+}
+
+// trace records dns, connect and tls phases as children of the parent
+// span. Parallel dual stack dials get a connect span per address.
+func (r *spanRecorder) trace(parent int) *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var dns, handshake int
+	connect := map[string]int{}
+	errString := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dns = r.begin(parent, "dns", otlpSpanKindInternal, time.Now())
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			r.finish(dns, errString(info.Err))
+		},
+		ConnectStart: func(_, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connect[addr] = r.begin(parent, "connect", otlpSpanKindInternal, time.Now())
+		},
+		ConnectDone: func(_, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if i, ok := connect[addr]; ok {
+				delete(connect, addr)
+				r.finish(i, errString(err), "net.peer.name", addr)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			handshake = r.begin(parent, "tls", otlpSpanKindInternal, time.Now())
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			r.finish(handshake, errString(err), "tls.version", tlsVersionName(state.Version))
+		},
+	}
+}
+
+func tlsVersionName(v uint16) string {
+	for name, version := range tlsVersions {
+		if version == v {
+			return name
+		}
+	}
+	return ""
+}
+
+func otlpID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// otlpPayload converts the recorded spans into an OTLP/HTTP JSON trace
+// export request.
+func (r *spanRecorder) otlpPayload(serviceName string) map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	traceID := otlpID(16)
+	ids := make([]string, len(r.spans))
+	spans := make([]map[string]interface{}, 0, len(r.spans))
+	for i, s := range r.spans {
+		ids[i] = otlpID(8)
+		end := s.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		attrs := make([]map[string]interface{}, 0, len(s.attrs))
+		for k, v := range s.attrs {
+			attrs = append(attrs, map[string]interface{}{"key": k, "value": map[string]string{"stringValue": v}})
+		}
+		status := map[string]interface{}{"code": otlpStatusOK}
+		if s.err != "" {
+			status = map[string]interface{}{"code": otlpStatusError, "message": s.err}
+		}
+		o := map[string]interface{}{
+			"traceId":           traceID,
+			"spanId":            ids[i],
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(end.UnixNano(), 10),
+			"attributes":        attrs,
+			"status":            status,
+		}
+		if s.parent >= 0 {
+			o["parentSpanId"] = ids[s.parent]
+		}
+		spans = append(spans, o)
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{map[string]interface{}{"key": "service.name", "value": map[string]string{"stringValue": serviceName}}},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "check_http", "version": version},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+// exportSpans finishes the root span and posts all spans to the OTLP/HTTP
+// endpoint, /v1/traces is appended if the endpoint has no path.
+func exportSpans(opts commandOpts, result *Result, state int) error {
+	rec := opts.spans
+	errMsg := ""
+	if state != OK {
+		errMsg = result.Message
+	}
+	rec.finish(0, errMsg, "check.state", stateNames[state], "http.url", result.URL, "http.status_code", strconv.Itoa(result.StatusCode))
+
+	endpoint, err := url.Parse(opts.OTLPEndpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/traces"
+	}
+	body, err := json.Marshal(rec.otlpPayload(opts.OTLPServiceName))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, h := range opts.OTLPHeader {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("invalid otlp header %q, expected Name: value", h)
+		}
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("otlp endpoint returned %s", res.Status)
+	}
+	return nil
+}
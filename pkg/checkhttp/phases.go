@@ -0,0 +1,73 @@
+package checkhttp
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases holds the durations of the connection phases of the first request.
+type Phases struct {
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	FirstByte time.Duration
+}
+
+// phaseTrace records the phase durations into p, relative to start for the
+// time to first byte. Only the first occurrence of each phase is kept so
+// redirects do not overwrite the timings of the checked url. Parallel dual
+// stack dials report their connect phases concurrently, keyed by address.
+func phaseTrace(p *Phases, start time.Time) *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var dnsStart, tlsStart time.Time
+	connectStart := map[string]time.Time{}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if p.DNS == 0 && !dnsStart.IsZero() {
+				p.DNS = time.Since(dnsStart)
+			}
+		},
+		ConnectStart: func(_, addr string) {
+			mu.Lock()
+			defer mu.Unlock()
+			connectStart[addr] = time.Now()
+		},
+		ConnectDone: func(_, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			started, ok := connectStart[addr]
+			delete(connectStart, addr)
+			if p.Connect == 0 && ok && err == nil {
+				p.Connect = time.Since(started)
+			}
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if p.TLS == 0 && !tlsStart.IsZero() {
+				p.TLS = time.Since(tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if p.FirstByte == 0 {
+				p.FirstByte = time.Since(start)
+			}
+		},
+	}
+}
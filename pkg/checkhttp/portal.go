@@ -0,0 +1,58 @@
+package checkhttp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/net/html"
+)
+
+// hasLoginForm returns true if the page contains a form with a password field.
+func hasLoginForm(page []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	inForm := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "form":
+				inForm = true
+			case "input":
+				if inForm && hasAttr && strings.EqualFold(tagAttributes(z)["type"], "password") {
+					return true
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "form" {
+				inForm = false
+			}
+		}
+	}
+}
+
+// portalIndicators lists the reasons why a response looks like a captive
+// portal or login page instead of the checked content.
+func portalIndicators(opts commandOpts, start *http.Request, res *http.Response, body []byte, bodySize uint64) []string {
+	var reasons []string
+	if res.Request.URL.Hostname() != start.URL.Hostname() {
+		reasons = append(reasons, fmt.Sprintf("redirected to %s", res.Request.URL.Host))
+	}
+	if isRedirect(res) {
+		if loc, err := res.Location(); err == nil && loc.Hostname() != start.URL.Hostname() {
+			reasons = append(reasons, fmt.Sprintf("redirect to %s", loc.Host))
+		}
+	}
+	if strings.Contains(res.Header.Get("Content-Type"), "html") && hasLoginForm(body) {
+		reasons = append(reasons, "login form found")
+	}
+	if opts.portalBaselineSize > 0 && bodySize < opts.portalBaselineSize/2 {
+		reasons = append(reasons, fmt.Sprintf("body of %s much smaller than baseline %s", humanize.Bytes(bodySize), humanize.Bytes(opts.portalBaselineSize)))
+	}
+	return reasons
+}
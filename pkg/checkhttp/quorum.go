@@ -0,0 +1,88 @@
+package checkhttp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// attempts tracks the results of the last requests. By default all
+// --consecutive requests have to succeed, with --min-success only that many
+// of them.
+type attempts struct {
+	results  []bool
+	size     int
+	required int
+	total    int
+}
+
+func newAttempts(opts commandOpts) *attempts {
+	size := opts.Consecutive
+	if size < 1 {
+		size = 1
+	}
+	required := size
+	if opts.MinSuccess > 0 {
+		required = opts.MinSuccess
+	}
+	return &attempts{size: size, required: required}
+}
+
+// add records a result, only the last --consecutive results are kept.
+func (a *attempts) add(success bool) {
+	a.total++
+	a.results = append(a.results, success)
+	if len(a.results) > a.size {
+		a.results = a.results[1:]
+	}
+}
+
+func (a *attempts) successes() int {
+	n := 0
+	for _, ok := range a.results {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// succeeded returns true once enough of the last requests succeeded.
+func (a *attempts) succeeded() bool {
+	return a.successes() >= a.required
+}
+
+// exhausted returns true when the remaining requests out of the first
+// --consecutive ones can no longer reach the required successes.
+func (a *attempts) exhausted() bool {
+	return a.successes()+a.size-a.total < a.required
+}
+
+// extendOutput appends text to the first line of a plugin output and adds
+// perfdata, keeping existing perfdata and long output in place.
+func extendOutput(msg, text string, perfdata ...string) string {
+	first, long, hasLong := strings.Cut(msg, "\n")
+	message, perf, _ := strings.Cut(first, " | ")
+	perfdata = append(strings.Fields(perf), perfdata...)
+	out := message + text
+	if len(perfdata) > 0 {
+		out += " | " + strings.Join(perfdata, " ")
+	}
+	if hasLong {
+		out += "\n" + long
+	}
+	return out
+}
+
+// waitForSummary describes the attempts made with --wait-for.
+func waitForSummary(msg string, requests int, start, firstSuccess time.Time) string {
+	waited := time.Since(start)
+	text := fmt.Sprintf(", %d attempts in %.3f seconds", requests, waited.Seconds())
+	if !firstSuccess.IsZero() {
+		text += fmt.Sprintf(", first success at %s", firstSuccess.Format(time.RFC3339))
+	}
+	return extendOutput(msg, text,
+		fmt.Sprintf("attempts=%d;;;1;", requests),
+		fmt.Sprintf("waited=%fs;;;0;", waited.Seconds()),
+	)
+}
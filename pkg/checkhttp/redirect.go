@@ -0,0 +1,139 @@
+package checkhttp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func isRedirect(res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return res.Header.Get("Location") != ""
+	}
+	return false
+}
+
+// nextRequest returns the request to follow up with if res is a redirect
+// (with --onredirect=follow) or an HTML meta refresh page (with
+// --follow-meta-refresh). It returns nil if res is the final response.
+func nextRequest(ctx context.Context, opts commandOpts, req *http.Request, res *http.Response) (*http.Request, error) {
+	if opts.OnRedirect == "follow" && isRedirect(res) {
+		loc, err := res.Location()
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect location: %v", err)
+		}
+		return redirectRequest(ctx, opts, req, loc, res.StatusCode)
+	}
+
+	if opts.FollowMetaRefresh && res.StatusCode >= 200 && res.StatusCode < 300 &&
+		strings.Contains(res.Header.Get("Content-Type"), "html") {
+		br := bufio.NewReaderSize(res.Body, metaRefreshSniffLen)
+		page, _ := br.Peek(metaRefreshSniffLen)
+		res.Body = peekedBody{br, res.Body}
+		target, ok := findMetaRefresh(page)
+		if !ok {
+			return nil, nil
+		}
+		loc, err := req.URL.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meta refresh url %q: %v", target, err)
+		}
+		return redirectRequest(ctx, opts, req, loc, http.StatusFound)
+	}
+
+	return nil, nil
+}
+
+// redirectRequest builds the follow up request for loc. Like browsers,
+// 301/302/303 redirects switch to GET without body while 307/308 resend
+// the original request.
+func redirectRequest(ctx context.Context, opts commandOpts, prev *http.Request, loc *url.URL, status int) (*http.Request, error) {
+	if status != http.StatusTemporaryRedirect && status != http.StatusPermanentRedirect {
+		opts.Post = ""
+		opts.PostFile = ""
+		opts.ExpectContinue = false
+		if opts.Method != "HEAD" {
+			opts.Method = "GET"
+		}
+	}
+	req, err := buildRequest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = loc
+	req.Host = loc.Host
+	if opts.RequestTarget == "absolute" {
+		setAbsoluteTarget(req)
+	}
+	if loc.Host != prev.URL.Host {
+		// do not leak credentials to other hosts
+		req.Header.Del("Authorization")
+	}
+	if opts.RequestIDHeader != "" {
+		req.Header.Set(opts.RequestIDHeader, prev.Header.Get(opts.RequestIDHeader))
+	}
+	return req.WithContext(prev.Context()), nil
+}
+
+// parseRedirectChain parses a chain like `https://a/ -> https://b/ -> 200`.
+// The final status code is optional.
+func parseRedirectChain(value string) (urls []string, status int, err error) {
+	for _, hop := range strings.Split(value, "->") {
+		hop = strings.TrimSpace(hop)
+		if hop == "" {
+			return nil, 0, fmt.Errorf("empty hop in redirect chain")
+		}
+		if status != 0 {
+			return nil, 0, fmt.Errorf("status code must be the last element of the redirect chain")
+		}
+		if len(hop) == 3 && hop[0] >= '1' && hop[0] <= '5' {
+			if _, err := fmt.Sscanf(hop, "%d", &status); err == nil {
+				continue
+			}
+		}
+		if _, err := url.Parse(hop); err != nil {
+			return nil, 0, fmt.Errorf("invalid url %q in redirect chain: %v", hop, err)
+		}
+		urls = append(urls, hop)
+	}
+	if len(urls) == 0 {
+		return nil, 0, fmt.Errorf("redirect chain must contain at least one url")
+	}
+	return urls, status, nil
+}
+
+// checkRedirectChain compares the requested urls and the final status with
+// --expect-redirect-chain.
+func checkRedirectChain(opts commandOpts, hops []redirectHop) (string, *reqError) {
+	chain := make([]string, len(hops))
+	for i, hop := range hops {
+		chain[i] = hop.url
+	}
+	status := hops[len(hops)-1].status
+	actual := strings.Join(chain, " -> ") + fmt.Sprintf(" -> %d", status)
+	mismatch := len(chain) != len(opts.redirectChain) ||
+		(opts.redirectChainStatus != 0 && opts.redirectChainStatus != status)
+	for i := 0; !mismatch && i < len(chain); i++ {
+		mismatch = chain[i] != opts.redirectChain[i]
+	}
+	if mismatch {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Redirect chain %q does not match %q from host on port %d", actual, opts.ExpectRedirectChain, opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("redirect chain of %d redirects to %s matched", len(chain)-1, chain[len(chain)-1]), nil
+}
+
+// redirectHop is a single request of a redirect chain.
+type redirectHop struct {
+	url      string
+	status   int
+	duration time.Duration
+}
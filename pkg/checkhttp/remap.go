@@ -0,0 +1,50 @@
+package checkhttp
+
+import (
+	"fmt"
+	"strings"
+)
+
+var stateByName = map[string]int{"ok": OK, "warning": WARNING, "critical": CRITICAL, "unknown": UNKNOWN}
+
+// parseRemap parses a list like `warning=critical,unknown=critical`.
+func parseRemap(value string) (map[int]int, error) {
+	remap := map[int]int{}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid remap %q, expected state=state", pair)
+		}
+		from, ok := stateByName[strings.ToLower(strings.TrimSpace(kv[0]))]
+		if !ok {
+			return nil, fmt.Errorf("unknown state %q", kv[0])
+		}
+		to, ok := stateByName[strings.ToLower(strings.TrimSpace(kv[1]))]
+		if !ok {
+			return nil, fmt.Errorf("unknown state %q", kv[1])
+		}
+		remap[from] = to
+	}
+	return remap, nil
+}
+
+// negateStates returns the mapping for --negate which swaps OK and CRITICAL.
+func negateStates(warning, unknown string) map[int]int {
+	return map[int]int{
+		OK:       CRITICAL,
+		CRITICAL: OK,
+		WARNING:  stateByName[warning],
+		UNKNOWN:  stateByName[unknown],
+	}
+}
+
+// finalState applies --negate and --remap to a check state.
+func finalState(opts commandOpts, state int) int {
+	if to, ok := opts.negate[state]; ok {
+		state = to
+	}
+	if to, ok := opts.remap[state]; ok {
+		state = to
+	}
+	return state
+}
@@ -0,0 +1,40 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckNegateRemap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{args: []string{"--negate"}, want: CRITICAL},
+		{args: []string{"--negate", "-u", "/missing", "-e", "200"}, want: OK},
+		{args: []string{"--remap", "ok=warning"}, want: WARNING},
+		{args: []string{"--negate", "--remap", "critical=unknown"}, want: UNKNOWN},
+		{args: []string{"--vhosts", "a,b", "--negate"}, want: CRITICAL},
+		// option errors stay unknown
+		{args: []string{"--negate", "--negate-unknown", "ok", "--remap", "bogus"}, want: UNKNOWN},
+		{args: []string{"--remap", "unknown=ok", "--tls-min", "1.3", "--tls-max", "1.2"}, want: UNKNOWN},
+	}
+	for _, tt := range tests {
+		args := append([]string{"-I", "127.0.0.1", "-p", port}, tt.args...)
+		var output bytes.Buffer
+		if state := Check(context.Background(), &output, args); state != tt.want {
+			t.Errorf("Check(%q) = %d, want %d: %s", args, state, tt.want, output.String())
+		}
+	}
+}
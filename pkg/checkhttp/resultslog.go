@@ -0,0 +1,106 @@
+package checkhttp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+var resultsLogColumns = []string{"timestamp", "state", "status", "size", "time", "dns", "connect", "tls", "first_byte", "url"}
+
+// resultsLogEntry is a single line of the results log.
+type resultsLogEntry struct {
+	Timestamp string  `json:"timestamp"`
+	State     string  `json:"state"`
+	Status    int     `json:"status"`
+	Size      uint64  `json:"size"`
+	Time      float64 `json:"time"`
+	DNS       float64 `json:"dns"`
+	Connect   float64 `json:"connect"`
+	TLS       float64 `json:"tls"`
+	FirstByte float64 `json:"first_byte"`
+	URL       string  `json:"url"`
+}
+
+func (e *resultsLogEntry) record() []string {
+	seconds := func(v float64) string { return strconv.FormatFloat(v, 'f', 6, 64) }
+	return []string{
+		e.Timestamp, e.State, strconv.Itoa(e.Status), strconv.FormatUint(e.Size, 10),
+		seconds(e.Time), seconds(e.DNS), seconds(e.Connect), seconds(e.TLS), seconds(e.FirstByte), e.URL,
+	}
+}
+
+// appendResultsLog appends the result to the --results-log file, rotating
+// the file when it would grow beyond --results-log-max-size.
+func appendResultsLog(opts commandOpts, result *Result, state int) error {
+	entry := &resultsLogEntry{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		State:     stateNames[state],
+		Status:    result.StatusCode,
+		Size:      result.Size,
+		Time:      result.Duration.Seconds(),
+		DNS:       result.Phases.DNS.Seconds(),
+		Connect:   result.Phases.Connect.Seconds(),
+		TLS:       result.Phases.TLS.Seconds(),
+		FirstByte: result.Phases.FirstByte.Seconds(),
+		URL:       result.URL,
+	}
+
+	var line bytes.Buffer
+	if opts.ResultsLogFormat == "jsonl" {
+		if err := json.NewEncoder(&line).Encode(entry); err != nil {
+			return err
+		}
+	} else {
+		w := csv.NewWriter(&line)
+		w.Write(entry.record())
+		w.Flush()
+	}
+
+	size := int64(0)
+	if st, err := os.Stat(opts.ResultsLog); err == nil {
+		size = st.Size()
+	}
+	if opts.resultsLogMaxSize > 0 && size > 0 && uint64(size)+uint64(line.Len()) > opts.resultsLogMaxSize {
+		if err := rotateResultsLog(opts.ResultsLog, opts.ResultsLogKeep); err != nil {
+			return err
+		}
+		size = 0
+	}
+	if size == 0 && opts.ResultsLogFormat == "csv" {
+		var header bytes.Buffer
+		w := csv.NewWriter(&header)
+		w.Write(resultsLogColumns)
+		w.Flush()
+		line = *bytes.NewBuffer(append(header.Bytes(), line.Bytes()...))
+	}
+
+	f, err := os.OpenFile(opts.ResultsLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotateResultsLog renames file to file.1, file.1 to file.2 and so on,
+// keeping at most keep old files.
+func rotateResultsLog(file string, keep int) error {
+	if keep < 1 {
+		return os.Remove(file)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", file, keep))
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", file, i), fmt.Sprintf("%s.%d", file, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(file, file+".1")
+}
@@ -0,0 +1,60 @@
+package checkhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// checkResumption requests the checked url again over a new connection and
+// reports whether the TLS session of the first handshake was resumed.
+func checkResumption(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request) (string, []string, *reqError) {
+	if req.URL.Scheme != "https" {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - TLS session resumption requires https from host on port %d", opts.Port),
+			UNKNOWN,
+		}
+	}
+	// the second request has to do a new handshake
+	client.CloseIdleConnections()
+	// 307 keeps the method and body of the checked request
+	resumeReq, err := redirectRequest(ctx, opts, req, req.URL, http.StatusTemporaryRedirect)
+	if err != nil {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Error in building resumption request: %v", err),
+			UNKNOWN,
+		}
+	}
+	start := time.Now()
+	res, err := client.Do(resumeReq)
+	if err != nil {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Error in resumption request: %v", err),
+			CRITICAL,
+		}
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, int64(opts.bufferSize)))
+	res.Body.Close()
+	duration := time.Since(start)
+
+	resumed := 0
+	if res.TLS != nil && res.TLS.DidResume {
+		resumed = 1
+	}
+	perfdata := []string{
+		fmt.Sprintf("resumed=%d;;;0;1", resumed),
+		fmt.Sprintf("resumption_time=%fs;;;0;", duration.Seconds()),
+	}
+	if resumed == 0 {
+		if opts.RequireResumption {
+			return "", nil, &reqError{
+				fmt.Sprintf("HTTP CRITICAL - TLS session was not resumed from host on port %d", opts.Port),
+				CRITICAL,
+			}
+		}
+		return "TLS session not resumed", perfdata, nil
+	}
+	return "TLS session resumed", perfdata, nil
+}
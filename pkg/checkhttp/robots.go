@@ -0,0 +1,163 @@
+package checkhttp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type robotsRule struct {
+	Allow bool
+	Path  string
+}
+
+type robotsGroup struct {
+	Agents []string
+	Rules  []robotsRule
+}
+
+type robotsTxt struct {
+	Groups   []*robotsGroup
+	Sitemaps []string
+}
+
+// parseRobots parses a robots.txt file according to RFC 9309 and returns
+// an error for lines which are not valid.
+func parseRobots(data []byte) (*robotsTxt, error) {
+	robots := &robotsTxt{}
+	var group *robotsGroup
+	lastWasAgent := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing colon in %q", lineNo, line)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !lastWasAgent {
+				group = &robotsGroup{}
+				robots.Groups = append(robots.Groups, group)
+			}
+			group.Agents = append(group.Agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			if group == nil {
+				return nil, fmt.Errorf("line %d: %s rule before any user-agent", lineNo, key)
+			}
+			if value != "" && !strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "*") {
+				return nil, fmt.Errorf("line %d: invalid path %q", lineNo, value)
+			}
+			group.Rules = append(group.Rules, robotsRule{Allow: key == "allow", Path: value})
+		case "sitemap":
+			robots.Sitemaps = append(robots.Sitemaps, value)
+		case "crawl-delay", "host", "clean-param", "request-rate", "visit-time", "noindex":
+			// widely used non-standard extensions
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNo, key)
+		}
+		lastWasAgent = false
+	}
+	return robots, scanner.Err()
+}
+
+// group returns the group applying to the given user agent, falling back
+// to the * group.
+func (r *robotsTxt) group(agent string) *robotsGroup {
+	agent = strings.ToLower(agent)
+	var fallback *robotsGroup
+	for _, g := range r.Groups {
+		for _, a := range g.Agents {
+			if a == agent {
+				return g
+			}
+			if a == "*" && fallback == nil {
+				fallback = g
+			}
+		}
+	}
+	return fallback
+}
+
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	parts := strings.Split(path, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// Disallowed evaluates the rules for agent using the longest match, allow
+// wins on equal length.
+func (r *robotsTxt) Disallowed(agent, path string) bool {
+	g := r.group(agent)
+	if g == nil {
+		return false
+	}
+	best := -1
+	disallowed := false
+	for _, rule := range g.Rules {
+		if rule.Path == "" || !robotsPattern(rule.Path).MatchString(path) {
+			continue
+		}
+		if len(rule.Path) > best || (len(rule.Path) == best && rule.Allow) {
+			best = len(rule.Path)
+			disallowed = !rule.Allow
+		}
+	}
+	return disallowed
+}
+
+func checkRobots(opts commandOpts, body []byte) (string, *reqError) {
+	robots, err := parseRobots(body)
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid robots.txt from host on port %d: %v", opts.Port, err),
+			CRITICAL,
+		}
+	}
+	for _, path := range opts.RobotsDisallow {
+		if !robots.Disallowed(opts.RobotsUserAgent, path) {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - robots.txt does not disallow %s for %s from host on port %d", path, opts.RobotsUserAgent, opts.Port),
+				CRITICAL,
+			}
+		}
+	}
+	for _, path := range opts.RobotsAllow {
+		if robots.Disallowed(opts.RobotsUserAgent, path) {
+			return "", &reqError{
+				fmt.Sprintf("HTTP CRITICAL - robots.txt disallows %s for %s from host on port %d", path, opts.RobotsUserAgent, opts.Port),
+				CRITICAL,
+			}
+		}
+	}
+	if opts.RobotsSitemap && len(robots.Sitemaps) == 0 {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - robots.txt does not reference a sitemap from host on port %d", opts.Port),
+			CRITICAL,
+		}
+	}
+	return fmt.Sprintf("robots.txt valid with %d groups", len(robots.Groups)), nil
+}
@@ -0,0 +1,98 @@
+package checkhttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// selfTestHandler answers every request with the configured --selftest-*
+// response.
+func selfTestHandler(opts commandOpts) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opts.SelfTestDelay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(opts.SelfTestDelay):
+			}
+		}
+		for _, h := range opts.SelfTestHeader {
+			kv := strings.SplitN(h, ":", 2)
+			if len(kv) == 2 {
+				w.Header().Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+			}
+		}
+		w.WriteHeader(opts.SelfTestStatus)
+		if r.Method != http.MethodHead {
+			fmt.Fprint(w, opts.SelfTestBody)
+		}
+	}
+}
+
+// startSelfTest starts a local test server (https with --ssl) and returns
+// its port.
+func startSelfTest(opts commandOpts) (*httptest.Server, int, error) {
+	for _, h := range opts.SelfTestHeader {
+		if !strings.Contains(h, ":") {
+			return nil, 0, fmt.Errorf("invalid selftest-header %q, expected Name: value", h)
+		}
+	}
+	srv := httptest.NewUnstartedServer(selfTestHandler(opts))
+	if opts.SSL {
+		cert, err := selfTestCertificate(opts.Hostname)
+		if err != nil {
+			return nil, 0, err
+		}
+		srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		srv.Close()
+		return nil, 0, err
+	}
+	p, _ := strconv.Atoi(port)
+	return srv, p, nil
+}
+
+// selfTestCertificate creates a self signed certificate for the checked
+// hostname, so the selftest server also passes certificate verification.
+func selfTestCertificate(hostname string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "check_http selftest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if name, err := punycodeHost(hostname); err == nil && name != "" && net.ParseIP(name) == nil {
+		template.DNSNames = append(template.DNSNames, name)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,140 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type serverTiming struct {
+	Name     string
+	Duration time.Duration
+	HasDur   bool
+}
+
+// splitQuoted splits s by sep while ignoring separators inside double quotes.
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	var cur strings.Builder
+	quoted := false
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == sep && !quoted:
+			parts = append(parts, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	return append(parts, cur.String())
+}
+
+// parseServerTiming parses all Server-Timing headers of a response, e.g.
+// `db;dur=53, app;dur=47.2;desc="Application"`.
+func parseServerTiming(h http.Header) []serverTiming {
+	var timings []serverTiming
+	for _, v := range h.Values("Server-Timing") {
+		for _, entry := range splitQuoted(v, ',') {
+			params := splitQuoted(entry, ';')
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			st := serverTiming{Name: name}
+			for _, p := range params[1:] {
+				kv := strings.SplitN(p, "=", 2)
+				if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "dur") {
+					continue
+				}
+				ms, err := strconv.ParseFloat(strings.Trim(strings.TrimSpace(kv[1]), `"`), 64)
+				if err != nil {
+					continue
+				}
+				st.Duration = time.Duration(ms * float64(time.Millisecond))
+				st.HasDur = true
+			}
+			timings = append(timings, st)
+		}
+	}
+	return timings
+}
+
+// parseMetricThresholds parses a list of name=duration values.
+func parseMetricThresholds(values []string) (map[string]time.Duration, error) {
+	thresholds := map[string]time.Duration{}
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid threshold %q, expected name=duration", v)
+		}
+		d, err := time.ParseDuration(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %v", v, err)
+		}
+		thresholds[kv[0]] = d
+	}
+	return thresholds, nil
+}
+
+func formatThreshold(thresholds map[string]time.Duration, name string) string {
+	if d, ok := thresholds[name]; ok {
+		return fmt.Sprintf("%f", d.Seconds())
+	}
+	return ""
+}
+
+// perfdataLabel replaces the characters that would break the label=value
+// syntax of perfdata.
+func perfdataLabel(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '=', '\'', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// checkServerTiming converts Server-Timing metrics into perfdata and
+// compares them against the configured thresholds. All metrics are checked
+// and the worst state is reported.
+func checkServerTiming(opts commandOpts, h http.Header) ([]string, *reqError) {
+	var perfdata []string
+	var worst *reqError
+	for _, st := range parseServerTiming(h) {
+		if !st.HasDur {
+			continue
+		}
+		if crit, ok := opts.serverTimingCritical[st.Name]; ok && st.Duration > crit {
+			if worst == nil || worst.code < CRITICAL {
+				worst = &reqError{
+					fmt.Sprintf("HTTP CRITICAL - Server-Timing %s took %.3f second (> %.3f) from host on port %d", st.Name, st.Duration.Seconds(), crit.Seconds(), opts.Port),
+					CRITICAL,
+				}
+			}
+		} else if warn, ok := opts.serverTimingWarning[st.Name]; ok && st.Duration > warn && worst == nil {
+			worst = &reqError{
+				fmt.Sprintf("HTTP WARNING - Server-Timing %s took %.3f second (> %.3f) from host on port %d", st.Name, st.Duration.Seconds(), warn.Seconds(), opts.Port),
+				WARNING,
+			}
+		}
+		perfdata = append(perfdata, fmt.Sprintf("st_%s=%fs;%s;%s;0;",
+			perfdataLabel(st.Name),
+			st.Duration.Seconds(),
+			formatThreshold(opts.serverTimingWarning, st.Name),
+			formatThreshold(opts.serverTimingCritical, st.Name)))
+	}
+	if worst != nil {
+		return nil, worst
+	}
+	return perfdata, nil
+}
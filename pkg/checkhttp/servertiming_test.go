@@ -0,0 +1,108 @@
+package checkhttp
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	tests := []struct {
+		headers []string
+		want    []serverTiming
+	}{
+		{
+			headers: []string{`db;dur=53, app;dur=47.2;desc="Application"`},
+			want: []serverTiming{
+				{Name: "db", Duration: 53 * time.Millisecond, HasDur: true},
+				{Name: "app", Duration: 47200 * time.Microsecond, HasDur: true},
+			},
+		},
+		{
+			headers: []string{`cache;desc="hit, miss; maybe"`, `total;DUR="1.5"`},
+			want: []serverTiming{
+				{Name: "cache"},
+				{Name: "total", Duration: 1500 * time.Microsecond, HasDur: true},
+			},
+		},
+		{
+			headers: []string{`, ;dur=1, bad;dur=x`},
+			want:    []serverTiming{{Name: "bad"}},
+		},
+	}
+	for _, tt := range tests {
+		h := http.Header{"Server-Timing": tt.headers}
+		got := parseServerTiming(h)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseServerTiming(%q) = %+v, want %+v", tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestPerfdataLabel(t *testing.T) {
+	tests := map[string]string{
+		"db":          "db",
+		"db=primary":  "db_primary",
+		"it's":        "it_s",
+		"cache miss":  "cache_miss",
+		"tab\tmetric": "tab_metric",
+	}
+	for name, want := range tests {
+		if got := perfdataLabel(name); got != want {
+			t.Errorf("perfdataLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCheckServerTiming(t *testing.T) {
+	opts := commandOpts{
+		Port:                 80,
+		serverTimingWarning:  map[string]time.Duration{"db": 10 * time.Millisecond, "app": 10 * time.Millisecond},
+		serverTimingCritical: map[string]time.Duration{"app": 100 * time.Millisecond},
+	}
+	tests := []struct {
+		header   string
+		wantCode int
+		wantMsg  string
+		wantPerf []string
+	}{
+		{
+			header:   "db;dur=5, app;dur=5, other;dur=1000",
+			wantCode: OK,
+			wantPerf: []string{
+				"st_db=0.005000s;0.010000;;0;",
+				"st_app=0.005000s;0.010000;0.100000;0;",
+				"st_other=1.000000s;;;0;",
+			},
+		},
+		{header: "db;dur=20, app;dur=5", wantCode: WARNING, wantMsg: "Server-Timing db took"},
+		{header: "app;dur=500", wantCode: CRITICAL, wantMsg: "Server-Timing app took"},
+		// a later critical metric wins over an earlier warning
+		{header: "db;dur=20, app;dur=500", wantCode: CRITICAL, wantMsg: "Server-Timing app took"},
+		{header: "app;dur=500, db;dur=20", wantCode: CRITICAL, wantMsg: "Server-Timing app took"},
+		{header: `my metric;dur=1`, wantCode: OK, wantPerf: []string{"st_my_metric=0.001000s;;;0;"}},
+	}
+	for _, tt := range tests {
+		h := http.Header{"Server-Timing": {tt.header}}
+		perfdata, err := checkServerTiming(opts, h)
+		if tt.wantCode == OK {
+			if err != nil {
+				t.Errorf("checkServerTiming(%q) failed: %v", tt.header, err)
+				continue
+			}
+			if !reflect.DeepEqual(perfdata, tt.wantPerf) {
+				t.Errorf("checkServerTiming(%q) = %q, want %q", tt.header, perfdata, tt.wantPerf)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("checkServerTiming(%q) succeeded, want state %d", tt.header, tt.wantCode)
+			continue
+		}
+		if err.code != tt.wantCode || !strings.Contains(err.msg, tt.wantMsg) {
+			t.Errorf("checkServerTiming(%q) = %d %q, want %d %q", tt.header, err.code, err.msg, tt.wantCode, tt.wantMsg)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// parseSigV4Scope parses the --aws-sigv4 value `region/service`.
+func parseSigV4Scope(s string) (string, string, error) {
+	region, service, ok := strings.Cut(s, "/")
+	if !ok || region == "" || service == "" || strings.Contains(service, "/") {
+		return "", "", fmt.Errorf("expected region/service, got %q", s)
+	}
+	return region, service, nil
+}
+
+// loadAWSCredentials reads the credentials from the environment, the ECS or
+// EKS Pod Identity container endpoint or the EC2 instance profile, in this
+// order.
+func loadAWSCredentials(ctx context.Context) (*awsCredentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		secret := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if secret == "" {
+			return nil, fmt.Errorf("AWS_ACCESS_KEY_ID is set without AWS_SECRET_ACCESS_KEY")
+		}
+		return &awsCredentials{id, secret, os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	// the metadata endpoints are link local and must not be proxied
+	client := &http.Client{Transport: &http.Transport{}}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://169.254.170.2"+uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		return fetchAWSCredentials(client, req)
+	}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		req, err := containerCredentialsRequest(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		return fetchAWSCredentials(client, req)
+	}
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, "http://169.254.169.254/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := metadataGet(client, tokenReq)
+	if err != nil {
+		return nil, fmt.Errorf("no credentials in environment and instance metadata not available: %v", err)
+	}
+	const credentialsURL = "http://169.254.169.254/latest/meta-data/iam/security-credentials/"
+	roleReq, err := http.NewRequestWithContext(ctx, http.MethodGet, credentialsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	roleReq.Header.Set("X-aws-ec2-metadata-token", token)
+	role, err := metadataGet(client, roleReq)
+	if err != nil {
+		return nil, fmt.Errorf("no instance profile: %v", err)
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, credentialsURL+role, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return fetchAWSCredentials(client, req)
+}
+
+// containerCredentialsRequest builds the request for the ECS or EKS Pod
+// Identity credentials endpoint in AWS_CONTAINER_CREDENTIALS_FULL_URI. Like
+// the AWS SDKs, plain http is only accepted for loopback and the container
+// endpoint addresses.
+func containerCredentialsRequest(ctx context.Context, uri string) (*http.Request, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AWS_CONTAINER_CREDENTIALS_FULL_URI: %v", err)
+	}
+	if u.Scheme == "http" {
+		ip := net.ParseIP(u.Hostname())
+		allowed := u.Hostname() == "localhost" || (ip != nil && ip.IsLoopback())
+		for _, addr := range []string{"169.254.170.2", "169.254.170.23", "fd00:ec2::23"} {
+			if ip != nil && ip.Equal(net.ParseIP(addr)) {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("AWS_CONTAINER_CREDENTIALS_FULL_URI host %s is not allowed over http", u.Hostname())
+		}
+	} else if u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid AWS_CONTAINER_CREDENTIALS_FULL_URI scheme %q", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE: %v", err)
+		}
+		token = string(data)
+	}
+	if token = strings.TrimSpace(token); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return req, nil
+}
+
+// metadataGet returns the body of a successful metadata request.
+func metadataGet(client *http.Client, req *http.Request) (string, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", req.URL, res.Status)
+	}
+	return string(body), nil
+}
+
+func fetchAWSCredentials(client *http.Client, req *http.Request) (*awsCredentials, error) {
+	body, err := metadataGet(client, req)
+	if err != nil {
+		return nil, err
+	}
+	creds := &awsCredentials{}
+	if err := json.Unmarshal([]byte(body), creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials from %s: %v", req.URL, err)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("incomplete credentials from %s", req.URL)
+	}
+	return creds, nil
+}
+
+// sigV4Transport signs every request with AWS Signature Version 4, so
+// redirects and repeated requests are signed as well.
+type sigV4Transport struct {
+	next    http.RoundTripper
+	creds   *awsCredentials
+	region  string
+	service string
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	payload, err := requestPayload(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read body for signing: %v", err)
+	}
+	signSigV4(req, payload, t.creds, t.region, t.service, time.Now())
+	return t.next.RoundTrip(req)
+}
+
+// CloseIdleConnections passes through to the wrapped transport, so
+// http.Client.CloseIdleConnections reaches the connection pool.
+func (t *sigV4Transport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// requestPayload reads the request body, leaving a fresh copy in req.
+func requestPayload(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	payload, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	return payload, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode encodes everything except the RFC 3986 unreserved characters.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sigV4CanonicalURI encodes each decoded path segment, twice for all
+// services but S3.
+func sigV4CanonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+		segment = awsURIEncode(segment)
+		if service != "s3" {
+			segment = awsURIEncode(segment)
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, "/")
+}
+
+// signSigV4 adds the x-amz headers and the Authorization header.
+func signSigV4(req *http.Request, payload []byte, creds *awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	payloadHash := sha256Hex(payload)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.Token)
+	}
+	headers := map[string]string{"host": host}
+	for _, name := range []string{"X-Amz-Date", "X-Amz-Content-Sha256", "X-Amz-Security-Token"} {
+		if v := req.Header.Get(name); v != "" {
+			headers[strings.ToLower(name)] = v
+		}
+	}
+	req.Header.Set("Authorization", sigV4Authorization(req, headers, payloadHash, creds, region, service, amzDate))
+}
+
+// sigV4Authorization returns the Authorization header value for req signed
+// over the given lower case headers.
+func sigV4Authorization(req *http.Request, headers map[string]string, payloadHash string, creds *awsCredentials, region, service, amzDate string) string {
+	scope := strings.Join([]string{amzDate[:8], region, service, "aws4_request"}, "/")
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := sigV4CanonicalURI(req.URL, service)
+	query := req.URL.Query()
+	var params []string
+	for k, values := range query {
+		for _, v := range values {
+			params = append(params, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	sort.Strings(params)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature)
+}
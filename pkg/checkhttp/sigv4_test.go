@@ -0,0 +1,183 @@
+package checkhttp
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sigV4TestCreds = &awsCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// TestSigV4Suite signs requests of the AWS Signature Version 4 test suite,
+// which signs only the host and x-amz-date headers.
+func TestSigV4Suite(t *testing.T) {
+	const unreserved = "-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		headers map[string]string
+		body    string
+		want    string
+	}{
+		{name: "get-vanilla", method: "GET", url: "/", want: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{name: "get-vanilla-query-order-key-case", method: "GET", url: "/?Param2=value2&Param1=value1", want: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{name: "get-vanilla-empty-query-key", method: "GET", url: "/?Param1=value1", want: "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb"},
+		{name: "get-unreserved", method: "GET", url: "/" + unreserved, want: "07ef7494c76fa4850883e2b006601f940f8a34d404d0cfa977f52a65bbf5f24f"},
+		{name: "get-vanilla-query-unreserved", method: "GET", url: "/?" + unreserved + "=" + unreserved, want: "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197"},
+		{name: "get-vanilla-utf8-query", method: "GET", url: "/?ሴ=bar", want: "2cdec8eed098649ff3a119c94853b13c643bcf08f8b0a1d91e12c9027818dd04"},
+		{name: "post-vanilla", method: "POST", url: "/", want: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{name: "post-vanilla-query", method: "POST", url: "/?Param1=value1", want: "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11"},
+		{
+			name: "post-x-www-form-urlencoded", method: "POST", url: "/",
+			headers: map[string]string{"content-type": "application/x-www-form-urlencoded"},
+			body:    "Param1=value1",
+			want:    "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, "https://example.amazonaws.com"+tt.url, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		headers := map[string]string{"host": "example.amazonaws.com", "x-amz-date": "20150830T123600Z"}
+		for k, v := range tt.headers {
+			headers[k] = v
+		}
+		auth := sigV4Authorization(req, headers, sha256Hex([]byte(tt.body)), sigV4TestCreds, "us-east-1", "service", "20150830T123600Z")
+		if !strings.HasSuffix(auth, "Signature="+tt.want) {
+			t.Errorf("%s: got %s, want signature %s", tt.name, auth, tt.want)
+		}
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=") {
+			t.Errorf("%s: unexpected credential scope in %s", tt.name, auth)
+		}
+	}
+}
+
+func TestSigV4SigningKey(t *testing.T) {
+	// example from the AWS documentation on deriving the signing key
+	key := hmacSHA256([]byte("AWS4"+sigV4TestCreds.SecretAccessKey), "20120215")
+	key = hmacSHA256(key, "us-east-1")
+	key = hmacSHA256(key, "iam")
+	key = hmacSHA256(key, "aws4_request")
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("signing key = %s, want %s", got, want)
+	}
+}
+
+func TestSigV4CanonicalURI(t *testing.T) {
+	tests := []struct {
+		path    string
+		service string
+		want    string
+	}{
+		{path: "", service: "execute-api", want: "/"},
+		{path: "/", service: "execute-api", want: "/"},
+		{path: "/documents and settings/", service: "execute-api", want: "/documents%2520and%2520settings/"},
+		{path: "/documents and settings/", service: "s3", want: "/documents%20and%20settings/"},
+		{path: "/a:b+c=d@e", service: "execute-api", want: "/a%253Ab%252Bc%253Dd%2540e"},
+		{path: "/a:b+c=d@e", service: "s3", want: "/a%3Ab%2Bc%3Dd%40e"},
+		{path: "/a%2Fb/c", service: "execute-api", want: "/a%252Fb/c"},
+		{path: "/a%2Fb/c", service: "s3", want: "/a%2Fb/c"},
+		{path: "/ሴ", service: "s3", want: "/%E1%88%B4"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse("https://example.amazonaws.com" + tt.path)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.path, err)
+		}
+		if got := sigV4CanonicalURI(u, tt.service); got != tt.want {
+			t.Errorf("sigV4CanonicalURI(%q, %s) = %q, want %q", tt.path, tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestSignSigV4(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/a b/c?z=1&a=x y&a=b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := *sigV4TestCreds
+	signSigV4(req, nil, &creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, " +
+		"Signature=e9f08a923c65c9404839efa0346ed525df604ae1c6b57b2e398fe3d216e4a7b9"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s, want %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != sha256Hex(nil) {
+		t.Errorf("X-Amz-Content-Sha256 = %s", got)
+	}
+
+	creds.Token = "token"
+	signSigV4(req, nil, &creds, "us-east-1", "service", time.Now())
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q, want token", got)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("security token is not signed: %s", req.Header.Get("Authorization"))
+	}
+}
+
+func TestParseSigV4Scope(t *testing.T) {
+	tests := []struct {
+		scope   string
+		region  string
+		service string
+		wantErr bool
+	}{
+		{scope: "eu-west-1/execute-api", region: "eu-west-1", service: "execute-api"},
+		{scope: "us-east-1/s3", region: "us-east-1", service: "s3"},
+		{scope: "us-east-1", wantErr: true},
+		{scope: "/s3", wantErr: true},
+		{scope: "us-east-1/", wantErr: true},
+		{scope: "us-east-1/s3/extra", wantErr: true},
+	}
+	for _, tt := range tests {
+		region, service, err := parseSigV4Scope(tt.scope)
+		if (err != nil) != tt.wantErr || region != tt.region || service != tt.service {
+			t.Errorf("parseSigV4Scope(%q) = %q, %q, %v", tt.scope, region, service, err)
+		}
+	}
+}
+
+func TestContainerCredentials(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"AccessKeyId":"id","SecretAccessKey":"secret","Token":"session"}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/v1/credentials")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "inline")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", writeTestFile(t, "token", "from-file\n"))
+	creds, err := loadAWSCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("loadAWSCredentials failed: %v", err)
+	}
+	if *creds != (awsCredentials{"id", "secret", "session"}) {
+		t.Errorf("credentials = %+v", creds)
+	}
+	if gotAuth != "from-file" {
+		t.Errorf("Authorization = %q, want the token file to win", gotAuth)
+	}
+
+	for _, uri := range []string{"http://example.com/creds", "ftp://127.0.0.1/creds"} {
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", uri)
+		if _, err := loadAWSCredentials(context.Background()); err == nil {
+			t.Errorf("loadAWSCredentials with %s succeeded, want error", uri)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package checkhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxSitemaps limits the number of child sitemaps fetched from an index.
+const maxSitemaps = 50
+
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// parseSitemap parses a (possibly gzip compressed) sitemap or sitemap index.
+func parseSitemap(data []byte) (*sitemapDoc, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	doc := &sitemapDoc{}
+	if err := xml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return nil, fmt.Errorf("unexpected root element <%s>", doc.XMLName.Local)
+	}
+	return doc, nil
+}
+
+// sitemapURLs collects all page urls, fetching child sitemaps of indexes.
+func sitemapURLs(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request, doc *sitemapDoc) ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, u := range doc.URLs {
+		if parsed, err := req.URL.Parse(strings.TrimSpace(u.Loc)); err == nil {
+			urls = append(urls, parsed)
+		}
+	}
+	for i, sm := range doc.Sitemaps {
+		if i >= maxSitemaps {
+			break
+		}
+		loc, err := req.URL.Parse(strings.TrimSpace(sm.Loc))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sitemap location %q: %v", sm.Loc, err)
+		}
+		_, body, err := fetchLink(ctx, client, opts, req, loc)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %v", loc, err)
+		}
+		child, err := parseSitemap(body)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %v", loc, err)
+		}
+		childURLs, err := sitemapURLs(ctx, client, opts, req, &sitemapDoc{URLs: child.URLs})
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, childURLs...)
+	}
+	return urls, nil
+}
+
+// checkSitemap verifies the entries of the fetched sitemap with a limited
+// number of concurrent requests.
+func checkSitemap(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request, body []byte) (string, []string, *reqError) {
+	doc, err := parseSitemap(body)
+	if err != nil {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid sitemap from host on port %d: %v", opts.Port, err),
+			CRITICAL,
+		}
+	}
+	urls, err := sitemapURLs(ctx, client, opts, req, doc)
+	if err != nil {
+		return "", nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %v", err),
+			CRITICAL,
+		}
+	}
+	total := len(urls)
+	if opts.SitemapSample > 0 && opts.SitemapSample < len(urls) {
+		rand.Shuffle(len(urls), func(i, j int) { urls[i], urls[j] = urls[j], urls[i] })
+		urls = urls[:opts.SitemapSample]
+	}
+
+	var mu sync.Mutex
+	var failed []string
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.SitemapConcurrency)
+	for _, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(u *url.URL) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, _, err := fetchLink(ctx, client, opts, req, u); err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s: %v", u, err))
+				mu.Unlock()
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	perfdata := []string{
+		fmt.Sprintf("sitemap_urls=%d;;;0;", total),
+		fmt.Sprintf("sitemap_checked=%d;;;0;", len(urls)),
+		fmt.Sprintf("sitemap_failed=%d;%s;%s;0;", len(failed), formatCount(opts.SitemapWarning), formatCount(opts.SitemapCritical)),
+	}
+	state := OK
+	switch {
+	case opts.SitemapCritical > 0 && len(failed) >= opts.SitemapCritical:
+		state = CRITICAL
+	case opts.SitemapWarning > 0 && len(failed) >= opts.SitemapWarning:
+		state = WARNING
+	}
+	if state != OK {
+		label := "CRITICAL"
+		if state == WARNING {
+			label = "WARNING"
+		}
+		return "", perfdata, &reqError{
+			fmt.Sprintf("HTTP %s - %d of %d sitemap entries failed from host on port %d\n%s", label, len(failed), len(urls), opts.Port, strings.Join(failed, "\n")),
+			state,
+		}
+	}
+	return fmt.Sprintf("%d of %d sitemap entries ok", len(urls)-len(failed), len(urls)), perfdata, nil
+}
+
+func formatCount(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,60 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// soft404Similarity is the token similarity above which the checked page is
+// considered the same as the error page for a random path.
+const soft404Similarity = 0.9
+
+// tokenSimilarity returns the Jaccard similarity of the word sets of a and b.
+func tokenSimilarity(a, b []byte) float64 {
+	setA := map[string]bool{}
+	for _, w := range bytes.Fields(a) {
+		setA[string(w)] = true
+	}
+	setB := map[string]bool{}
+	for _, w := range bytes.Fields(b) {
+		setB[string(w)] = true
+	}
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	common := 0
+	for w := range setA {
+		if setB[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(setA)+len(setB)-common)
+}
+
+// checkSoft404 fetches a random path on the same host. If the server answers
+// successfully with (nearly) the same page, the checked page is an error page.
+func checkSoft404(ctx context.Context, client *http.Client, opts commandOpts, req *http.Request, res *http.Response, page []byte) (string, *reqError) {
+	id, err := newUUID()
+	if err != nil {
+		return "", &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Could not generate random path: %v", err),
+			UNKNOWN,
+		}
+	}
+	u, _ := req.URL.Parse("/check_http-" + id)
+	probe, probeBody, err := fetchLink(ctx, client, opts, req, u)
+	if err != nil {
+		// random paths are expected to fail
+		return "No soft 404", nil
+	}
+	similarity := tokenSimilarity(page, probeBody)
+	if probe.StatusCode == res.StatusCode && similarity >= soft404Similarity {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Response looks like a soft 404, random path %s returned the same page (%.0f%% similar) from host on port %d", u.Path, similarity*100, opts.Port),
+			CRITICAL,
+		}
+	}
+	return "No soft 404", nil
+}
@@ -0,0 +1,92 @@
+package checkhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// tcpInfo holds the kernel statistics of a TCP connection.
+type tcpInfo struct {
+	RTT          time.Duration
+	RTTVar       time.Duration
+	Retransmits  uint32
+	DeliveryRate uint64
+	FastOpen     bool
+}
+
+// tcpInfoConn reads the TCP statistics of the underlying connection and
+// keeps the last snapshot when the connection gets closed, since the
+// transport may close it as soon as the body has been read.
+type tcpInfoConn struct {
+	net.Conn
+	mu     sync.Mutex
+	info   *tcpInfo
+	err    error
+	closed bool
+}
+
+func (c *tcpInfoConn) snapshot() {
+	info, err := readTCPInfo(c.Conn)
+	if err != nil {
+		c.err = err
+		return
+	}
+	c.info, c.err = info, nil
+}
+
+func (c *tcpInfoConn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.snapshot()
+		c.closed = true
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// Info returns the current statistics or the last snapshot of a closed connection.
+func (c *tcpInfoConn) Info() (*tcpInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.snapshot()
+	}
+	return c.info, c.err
+}
+
+// findTCPInfoConn unwraps conn until the tcpInfoConn is found.
+func findTCPInfoConn(conn net.Conn) *tcpInfoConn {
+	for {
+		switch c := conn.(type) {
+		case *tcpInfoConn:
+			return c
+		case *tls.Conn:
+			conn = c.NetConn()
+		case *recordingConn:
+			conn = c.Conn
+		default:
+			return nil
+		}
+	}
+}
+
+// readConnTCPInfo returns the TCP statistics of the connection conn was dialed with.
+func readConnTCPInfo(conn net.Conn) (*tcpInfo, error) {
+	c := findTCPInfoConn(conn)
+	if c == nil {
+		return nil, fmt.Errorf("no tcp connection information available")
+	}
+	return c.Info()
+}
+
+func (info *tcpInfo) perfdata() []string {
+	return []string{
+		fmt.Sprintf("tcp_rtt=%fs;;;0;", info.RTT.Seconds()),
+		fmt.Sprintf("tcp_rttvar=%fs;;;0;", info.RTTVar.Seconds()),
+		fmt.Sprintf("tcp_retransmits=%d;;;0;", info.Retransmits),
+		fmt.Sprintf("tcp_delivery_rate=%dB;;;0;", info.DeliveryRate),
+	}
+}
@@ -0,0 +1,59 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	tcpInfoSupported     = true
+	tcpFastOpenSupported = true
+
+	// tcpiOptSynData is set when the data sent in the SYN was acknowledged
+	tcpiOptSynData = 0x20
+)
+
+// setTCPFastOpen enables client side TCP Fast Open on the socket before connecting.
+func setTCPFastOpen(_, _ string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_CONNECT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+func readTCPInfo(conn net.Conn) (*tcpInfo, error) {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("connection does not expose a socket")
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var info *unix.TCPInfo
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		info, sockErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sockErr != nil {
+		return nil, sockErr
+	}
+	return &tcpInfo{
+		RTT:          time.Duration(info.Rtt) * time.Microsecond,
+		RTTVar:       time.Duration(info.Rttvar) * time.Microsecond,
+		Retransmits:  info.Total_retrans,
+		DeliveryRate: info.Delivery_rate,
+		FastOpen:     info.Options&tcpiOptSynData != 0,
+	}, nil
+}
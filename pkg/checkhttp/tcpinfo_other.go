@@ -0,0 +1,22 @@
+//go:build !linux
+
+package checkhttp
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+const (
+	tcpInfoSupported     = false
+	tcpFastOpenSupported = false
+)
+
+func setTCPFastOpen(_, _ string, _ syscall.RawConn) error {
+	return fmt.Errorf("TCP Fast Open is only supported on Linux")
+}
+
+func readTCPInfo(_ net.Conn) (*tcpInfo, error) {
+	return nil, fmt.Errorf("TCP_INFO is only supported on Linux")
+}
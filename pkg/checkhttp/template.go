@@ -0,0 +1,148 @@
+package checkhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Result is the structured outcome of a check as passed to --output-template.
+type Result struct {
+	// State is the plugin exit code and StateName its name, e.g. OK.
+	State     int
+	StateName string
+	// Message is the default output without perfdata and long output.
+	Message    string
+	Perfdata   string
+	URL        string
+	StatusCode int
+	StatusLine string
+	Headers    http.Header
+	Size       uint64
+	Duration   time.Duration
+	Matched    []string
+	// Extracted holds the values of --extract by name.
+	Extracted map[string]interface{}
+	// Phases holds the connection phase durations of the first request.
+	Phases Phases
+}
+
+// Seconds returns the response time in seconds.
+func (r *Result) Seconds() float64 {
+	return r.Duration.Seconds()
+}
+
+// parseExtract parses name=$.json.path values.
+func parseExtract(values []string) (map[string]string, error) {
+	extract := map[string]string{}
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || !strings.HasPrefix(kv[1], "$") {
+			return nil, fmt.Errorf("invalid extract %q, expected name=$.json.path", v)
+		}
+		extract[kv[0]] = kv[1]
+	}
+	return extract, nil
+}
+
+// extractJSON resolves all --extract paths against the buffered body.
+func extractJSON(opts commandOpts, b *capWriter) (map[string]interface{}, *reqError) {
+	if b.Discarded() > 0 {
+		return nil, &reqError{
+			fmt.Sprintf("HTTP UNKNOWN - Response body exceeds max-buffer-size %s, cannot parse JSON", opts.MaxBufferSize),
+			UNKNOWN,
+		}
+	}
+	doc, err := parseJSONBody(b.Bytes())
+	if err != nil {
+		return nil, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Invalid JSON in response from host on port %d: %v", opts.Port, err),
+			CRITICAL,
+		}
+	}
+	extracted := map[string]interface{}{}
+	for name, path := range opts.extract {
+		value, err := jsonPathLookup(doc, path)
+		if err != nil {
+			return nil, &reqError{
+				fmt.Sprintf("HTTP CRITICAL - Could not extract %s: %v from host on port %d", name, err, opts.Port),
+				CRITICAL,
+			}
+		}
+		extracted[name] = value
+	}
+	return extracted, nil
+}
+
+// loadTemplate parses the template from a file or, if no such file exists,
+// from the value itself.
+func loadTemplate(value string) (*template.Template, error) {
+	text := value
+	if data, err := os.ReadFile(value); err == nil {
+		text = strings.TrimRight(string(data), "\n")
+	}
+	return template.New("output").Option("missingkey=zero").Parse(text)
+}
+
+// writeOutput prints the check output, rendered through the output template
+// if one is configured. Perfdata and long output are kept as they are. The
+// returned exit code has --negate and --remap applied.
+func writeOutput(output io.Writer, opts commandOpts, state int, msg string) int {
+	first, long, _ := strings.Cut(msg, "\n")
+	message, perfdata, _ := strings.Cut(first, " | ")
+	result := opts.result
+	result.State = state
+	result.StateName = stateNames[state]
+	result.Message = message
+	result.Perfdata = perfdata
+	// negate and remapping only apply to check results, option errors are
+	// reported before and stay unknown
+	final := finalState(opts, state)
+
+	if opts.ResultsLog != "" {
+		if err := appendResultsLog(opts, result, final); err != nil {
+			log.Printf("could not write results log: %v", err)
+		}
+	}
+	if opts.StatsdAddr != "" {
+		if err := sendStatsd(opts, result, final); err != nil {
+			log.Printf("could not send statsd metrics: %v", err)
+		}
+	}
+	if opts.spans != nil {
+		if err := exportSpans(opts, result, final); err != nil {
+			log.Printf("could not export spans: %v", err)
+		}
+	}
+	if opts.GraphiteAddr != "" {
+		if err := sendGraphite(opts, result, final); err != nil {
+			log.Printf("could not send graphite metrics: %v", err)
+		}
+	}
+
+	if opts.outputTemplate == nil {
+		fmt.Fprint(output, msg)
+		return final
+	}
+
+	var buf bytes.Buffer
+	if err := opts.outputTemplate.Execute(&buf, result); err != nil {
+		fmt.Fprintf(output, "%s (output template failed: %v)", msg, err)
+		return UNKNOWN
+	}
+	rendered := buf.String()
+	if perfdata != "" {
+		rendered += " | " + perfdata
+	}
+	if long != "" {
+		rendered += "\n" + long
+	}
+	fmt.Fprint(output, rendered)
+	return final
+}
@@ -0,0 +1,46 @@
+package checkhttp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// parseThroughput parses a rate like `5MB/s`, the `/s` suffix is optional.
+func parseThroughput(s string) (uint64, error) {
+	return humanize.ParseBytes(strings.TrimSuffix(strings.TrimSpace(s), "/s"))
+}
+
+// checkThroughput compares the download rate of the response body against
+// the min-throughput thresholds. The perfdata is returned with errors as well.
+func checkThroughput(opts commandOpts, size uint64, duration time.Duration) ([]string, *reqError) {
+	if duration <= 0 {
+		return nil, nil
+	}
+	rate := float64(size) / duration.Seconds()
+	// bytes per second has no valid unit of measurement in perfdata
+	perfdata := []string{fmt.Sprintf("throughput=%.0f;%s;%s;0;", rate, formatMinThreshold(opts.minThroughputWarning), formatMinThreshold(opts.minThroughput))}
+	if opts.minThroughput > 0 && rate < float64(opts.minThroughput) {
+		return perfdata, &reqError{
+			fmt.Sprintf("HTTP CRITICAL - Download throughput %s/s is below %s/s from host on port %d", humanize.Bytes(uint64(rate)), humanize.Bytes(opts.minThroughput), opts.Port),
+			CRITICAL,
+		}
+	}
+	if opts.minThroughputWarning > 0 && rate < float64(opts.minThroughputWarning) {
+		return perfdata, &reqError{
+			fmt.Sprintf("HTTP WARNING - Download throughput %s/s is below %s/s from host on port %d", humanize.Bytes(uint64(rate)), humanize.Bytes(opts.minThroughputWarning), opts.Port),
+			WARNING,
+		}
+	}
+	return perfdata, nil
+}
+
+// formatMinThreshold formats a lower bound as nagios range.
+func formatMinThreshold(v uint64) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:", v)
+}
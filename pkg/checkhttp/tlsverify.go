@@ -0,0 +1,223 @@
+package checkhttp
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// certificateError returns the reason of a failed server certificate
+// verification.
+func certificateError(err error) (string, bool) {
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		err = verifyErr.Err
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostname), errors.As(err, &invalid):
+		return err.Error(), true
+	case verifyErr != nil:
+		return verifyErr.Err.Error(), true
+	}
+	return "", false
+}
+
+// loadCertPool reads the PEM encoded CA certificates of file and of all
+// files in dir.
+func loadCertPool(file, dir string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	var files []string
+	if file != "" {
+		files = append(files, file)
+	}
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	found := false
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if pool.AppendCertsFromPEM(data) {
+			found = true
+		} else if f == file {
+			return nil, fmt.Errorf("no PEM certificates found in %s", f)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM certificates found in %s", dir)
+	}
+	return pool, nil
+}
+
+// verifyRoots returns the CA certificates from --ca-file and --ca-path or
+// the system trust store.
+func verifyRoots(opts commandOpts) (*x509.CertPool, error) {
+	if opts.CAFile != "" || opts.CAPath != "" {
+		return loadCertPool(opts.CAFile, opts.CAPath)
+	}
+	return x509.SystemCertPool()
+}
+
+// loadPKCS12 reads a client certificate, its chain and private key from a
+// PKCS#12 (.p12/.pfx) bundle.
+func loadPKCS12(file, password string) (tls.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, first, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return tls.Certificate{}, fmt.Errorf("unsupported private key type %T", key)
+	}
+	// the bags are not ordered, the leaf is the certificate of the key
+	certs := append([]*x509.Certificate{first}, caCerts...)
+	leaf := -1
+	for i, c := range certs {
+		if pub, ok := c.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && pub.Equal(signer.Public()) {
+			leaf = i
+			break
+		}
+	}
+	if leaf < 0 {
+		return tls.Certificate{}, fmt.Errorf("no certificate matches the private key")
+	}
+	cert := tls.Certificate{
+		Certificate: [][]byte{certs[leaf].Raw},
+		PrivateKey:  key,
+		Leaf:        certs[leaf],
+	}
+	for i, c := range certs {
+		if i != leaf {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+	}
+	return cert, nil
+}
+
+// alertProtocolVersion is the alert a server sends when it supports none of
+// the offered TLS versions.
+const alertProtocolVersion = tls.AlertError(70)
+
+// protocolVersionError returns true if the handshake failed because client
+// and server have no TLS version in common, either reported by the server
+// alert or detected locally.
+func protocolVersionError(err error) bool {
+	var alertErr tls.AlertError
+	if errors.As(err, &alertErr) {
+		return alertErr == alertProtocolVersion
+	}
+	// received alerts are reported as remote net.OpError wrapping an
+	// unexported alert type, which formats like the matching AlertError
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		return opErr.Err.Error() == alertProtocolVersion.Error()
+	}
+	// the server selected a version outside of the configured bounds
+	return strings.Contains(err.Error(), "server selected unsupported protocol version")
+}
+
+// tlsVersionBounds describes the TLS versions allowed by --tls-min and
+// --tls-max.
+func tlsVersionBounds(opts commandOpts) string {
+	switch {
+	case opts.TLSMinVersion != "" && opts.TLSMaxVersion != "":
+		return fmt.Sprintf("TLS %s to %s", opts.TLSMinVersion, opts.TLSMaxVersion)
+	case opts.TLSMinVersion != "":
+		return fmt.Sprintf("TLS %s or newer", opts.TLSMinVersion)
+	default:
+		return fmt.Sprintf("TLS %s or older", opts.TLSMaxVersion)
+	}
+}
+
+// parseCipherSuites converts a comma-separated list of Go cipher suite
+// names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, into their ids.
+func parseCipherSuites(list string) ([]uint16, error) {
+	known := map[string]uint16{}
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[s.Name] = s.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// tlsSummary describes the negotiated TLS version, cipher suite and ALPN
+// protocol of a connection.
+func tlsSummary(state *tls.ConnectionState) string {
+	alpn := state.NegotiatedProtocol
+	if alpn == "" {
+		alpn = "none"
+	}
+	return fmt.Sprintf("TLS %s, cipher %s, ALPN %s", tlsVersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), alpn)
+}
+
+// responseProto returns the ALPN style name of the response protocol, e.g.
+// h2 or http/1.1.
+func responseProto(res *http.Response) string {
+	if res.ProtoMajor == 2 {
+		return "h2"
+	}
+	return strings.ToLower(res.Proto)
+}
+
+// tlsDialer dials TLS connections with the server name chosen per address,
+// the transport would use the fixed name of the config for every host.
+func tlsDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), tlsConfig *tls.Config, serverName func(addr string) string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := tlsConfig.Clone()
+		cfg.ServerName = serverName(addr)
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		err = tlsConn.HandshakeContext(ctx)
+		if trace != nil && trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
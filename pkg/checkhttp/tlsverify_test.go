@@ -0,0 +1,74 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProtocolVersionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: alertProtocolVersion, want: true},
+		{err: tls.AlertError(40), want: false},
+		{err: &net.OpError{Op: "remote error", Err: alertProtocolVersion}, want: true},
+		{err: &net.OpError{Op: "remote error", Err: tls.AlertError(42)}, want: false},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: false},
+		{err: errors.New("tls: server selected unsupported protocol version 301"), want: true},
+		{err: errors.New("protocol version not supported by the proxy"), want: false},
+	}
+	for _, tt := range tests {
+		if got := protocolVersionError(tt.err); got != tt.want {
+			t.Errorf("protocolVersionError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTLSVersionBounds(t *testing.T) {
+	tests := []struct {
+		minVersion uint16
+		maxVersion uint16
+		args       []string
+		wantMsg    string
+	}{
+		{
+			maxVersion: tls.VersionTLS12,
+			args:       []string{"--tls-min", "1.3"},
+			wantMsg:    "Server does not support TLS 1.3 or newer",
+		},
+		{
+			minVersion: tls.VersionTLS13,
+			args:       []string{"--tls-max", "1.2"},
+			wantMsg:    "Server does not support TLS 1.2 or older",
+		},
+		{
+			minVersion: tls.VersionTLS13,
+			args:       []string{"--tls-min", "1.1", "--tls-max", "1.2"},
+			wantMsg:    "Server does not support TLS 1.1 to 1.2",
+		},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		srv.TLS = &tls.Config{MinVersion: tt.minVersion, MaxVersion: tt.maxVersion}
+		srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+		srv.StartTLS()
+		_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+		args := append([]string{"-H", "127.0.0.1", "-p", port, "-S", "-k"}, tt.args...)
+		var output bytes.Buffer
+		state := Check(context.Background(), &output, args)
+		srv.Close()
+		if state != CRITICAL || !strings.Contains(output.String(), tt.wantMsg) {
+			t.Errorf("Check(%q) = %d: %s, want %q", args, state, output.String(), tt.wantMsg)
+		}
+	}
+}
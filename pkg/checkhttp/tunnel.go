@@ -0,0 +1,109 @@
+package checkhttp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// checkTunnel opens a CONNECT tunnel to the checked host through the proxy
+// without sending the request itself.
+func checkTunnel(ctx context.Context, rt http.RoundTripper, opts commandOpts) (int, string) {
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return UNKNOWN, "HTTP UNKNOWN - tunnel-only is not supported with this transport"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL(opts), nil)
+	if err != nil {
+		return UNKNOWN, fmt.Sprintf("HTTP UNKNOWN - %v", err)
+	}
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		return UNKNOWN, fmt.Sprintf("HTTP UNKNOWN - Could not determine proxy: %v", err)
+	}
+	if proxyURL == nil {
+		return UNKNOWN, "HTTP UNKNOWN - tunnel-only requires a proxy"
+	}
+
+	start := time.Now()
+	tunnel, err := connectTunnel(ctx, transport, proxyURL, canonicalAddr(opts), opts.UserAgent)
+	if err != nil {
+		return CRITICAL, fmt.Sprintf("HTTP CRITICAL - Proxy %s: %v", proxyURL.Redacted(), err)
+	}
+	tunnel.conn.Close()
+	duration := time.Since(start)
+
+	statusLine := fmt.Sprintf("%s %s", tunnel.res.Proto, tunnel.res.Status)
+	if tunnel.res.StatusCode < 200 || tunnel.res.StatusCode >= 300 {
+		return CRITICAL, fmt.Sprintf("HTTP CRITICAL - Proxy %s refused CONNECT to %s: %s", proxyURL.Redacted(), canonicalAddr(opts), statusLine)
+	}
+	return OK, fmt.Sprintf("HTTP OK - Proxy %s tunnel to %s established, %s in %.3f second response time | time=%fs;;;0.000000",
+		proxyURL.Redacted(), canonicalAddr(opts), statusLine, duration.Seconds(), duration.Seconds())
+}
+
+type proxyTunnel struct {
+	conn net.Conn
+	res  *http.Response
+}
+
+// connectTunnel sends a CONNECT request for addr to the proxy and returns
+// its response.
+func connectTunnel(ctx context.Context, transport *http.Transport, proxyURL *url.URL, addr, userAgent string) (*proxyTunnel, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := transport.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		// the proxy is verified like the checked host (--insecure, --ca-file, --ca-path)
+		proxyConfig := &tls.Config{ServerName: proxyURL.Hostname(), InsecureSkipVerify: true}
+		if base := transport.TLSClientConfig; base != nil {
+			proxyConfig.RootCAs = base.RootCAs
+			proxyConfig.InsecureSkipVerify = base.InsecureSkipVerify
+		}
+		tlsConn := tls.Client(conn, proxyConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	connectReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{"User-Agent": {userAgent}},
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		connectReq.SetBasicAuth(u.Username(), password)
+		connectReq.Header.Set("Proxy-Authorization", connectReq.Header.Get("Authorization"))
+		connectReq.Header.Del("Authorization")
+	}
+	if err := connectReq.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &proxyTunnel{conn, res}, nil
+}
@@ -0,0 +1,74 @@
+package checkhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// applyURL fills hostname, port, uri, ssl and basic auth credentials from
+// a URL given as positional argument. Explicit options take precedence.
+func applyURL(opts *commandOpts, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "https":
+		opts.SSL = true
+	case "http":
+	default:
+		return fmt.Errorf("unsupported scheme %q, expected http or https", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", u.Redacted())
+	}
+	if opts.Hostname == "" {
+		opts.Hostname = u.Host
+	}
+	if opts.URI == "/" {
+		opts.URI = u.RequestURI()
+	}
+	if u.User != nil && opts.Authorization == "" {
+		password, _ := u.User.Password()
+		opts.Authorization = u.User.Username() + ":" + password
+	}
+	return nil
+}
+
+// credentialHeaders matches header lines carrying credentials in a dump.
+var credentialHeaders = regexp.MustCompile(`(?im)^((?:proxy-)?authorization):.*$`)
+
+// dumpRequest dumps the request like httputil.DumpRequest with credentials
+// masked.
+func dumpRequest(req *http.Request, body bool) []byte {
+	dump, _ := httputil.DumpRequest(req, body)
+	return credentialHeaders.ReplaceAll(dump, []byte("$1: ********\r"))
+}
+
+// setRawRequestURI makes the request line carry uri verbatim. Uris starting
+// with a double slash would be taken as network path by the transport and
+// are sent in absolute-form instead.
+func setRawRequestURI(req *http.Request, uri string) {
+	req.URL.RawQuery = ""
+	if strings.HasPrefix(uri, "//") {
+		uri = "//" + req.URL.Host + uri
+	}
+	req.URL.Opaque = uri
+}
+
+// setAbsoluteTarget makes the request line carry the full url in
+// absolute-form, as sent to proxies.
+func setAbsoluteTarget(req *http.Request) {
+	target := req.URL.Opaque
+	if target == "" {
+		target = req.URL.EscapedPath()
+	}
+	if !strings.HasPrefix(target, "//") {
+		target = "//" + req.URL.Host + target
+	}
+	req.URL.Opaque = target
+}
@@ -0,0 +1,84 @@
+package checkhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// set at build time with
+// -ldflags "-X pkg/checkhttp.commit=... -X pkg/checkhttp.buildDate=..."
+var (
+	commit    string
+	buildDate string
+)
+
+type moduleVersion struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+type versionInfo struct {
+	Version   string          `json:"version"`
+	Commit    string          `json:"commit,omitempty"`
+	BuildDate string          `json:"build_date,omitempty"`
+	Compiler  string          `json:"compiler"`
+	GoVersion string          `json:"go_version"`
+	OS        string          `json:"os"`
+	Arch      string          `json:"arch"`
+	Modules   []moduleVersion `json:"modules,omitempty"`
+}
+
+// buildVersionInfo collects the version metadata, falling back to the vcs
+// information embedded by the go tool when no ldflags were given.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		Compiler:  runtime.Compiler,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.BuildDate == "":
+			info.BuildDate = s.Value
+		}
+	}
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		info.Modules = append(info.Modules, moduleVersion{dep.Path, dep.Version})
+	}
+	return info
+}
+
+func printVersion(output io.Writer, format string) {
+	info := buildVersionInfo()
+	if format == "json" {
+		data, _ := json.Marshal(info)
+		fmt.Fprintf(output, "%s", data)
+		return
+	}
+	fmt.Fprintf(output, `%s Compiler: %s %s`,
+		info.Version,
+		info.Compiler,
+		info.GoVersion)
+	if info.Commit != "" {
+		fmt.Fprintf(output, " Commit: %s", info.Commit)
+	}
+	if info.BuildDate != "" {
+		fmt.Fprintf(output, " Build date: %s", info.BuildDate)
+	}
+}
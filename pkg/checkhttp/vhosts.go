@@ -0,0 +1,64 @@
+package checkhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// stateRank orders states by severity, UNKNOWN ranks between WARNING and CRITICAL.
+var stateRank = map[int]int{OK: 0, WARNING: 1, UNKNOWN: 2, CRITICAL: 3}
+
+var stateNames = map[int]string{OK: "OK", WARNING: "WARNING", CRITICAL: "CRITICAL", UNKNOWN: "UNKNOWN"}
+
+// checkVHosts runs the check for every virtual host against the same address
+// and reports the worst state with one line per virtual host.
+func checkVHosts(ctx context.Context, output io.Writer, opts commandOpts) int {
+	var vhosts []string
+	for _, v := range strings.Split(opts.VHosts, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			vhosts = append(vhosts, v)
+		}
+	}
+	if len(vhosts) == 0 {
+		fmt.Fprintf(output, "vhosts must contain at least one host name\n")
+		return UNKNOWN
+	}
+
+	state := OK
+	failed := 0
+	var details []string
+	for _, vhost := range vhosts {
+		vhostOpts := opts
+		vhostOpts.Hostname = vhost
+		vhostOpts.ServerName = vhost
+		// negate and remapping apply to the combined state only
+		vhostOpts.negate = nil
+		vhostOpts.remap = nil
+		if host, _, err := net.SplitHostPort(vhost); err == nil {
+			vhostOpts.ServerName = host
+		}
+
+		var buf bytes.Buffer
+		code := run(ctx, &buf, vhostOpts)
+		msg, _, _ := strings.Cut(strings.TrimSpace(buf.String()), " | ")
+		details = append(details, fmt.Sprintf("%s: %s", vhost, msg))
+		if code != OK {
+			failed++
+		}
+		if stateRank[code] > stateRank[state] {
+			state = code
+		}
+	}
+
+	summary := fmt.Sprintf("all %d vhosts OK", len(vhosts))
+	if failed > 0 {
+		summary = fmt.Sprintf("%d of %d vhosts failed", failed, len(vhosts))
+	}
+	fmt.Fprintf(output, "HTTP %s - %s | vhosts_failed=%d;;;0;%d\n%s",
+		stateNames[state], summary, failed, len(vhosts), strings.Join(details, "\n"))
+	return finalState(opts, state)
+}
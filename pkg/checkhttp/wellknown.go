@@ -0,0 +1,100 @@
+package checkhttp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// wellKnownPaths maps the --well-known presets to their request uri.
+var wellKnownPaths = map[string]string{
+	"security.txt":         "/.well-known/security.txt",
+	"change-password":      "/.well-known/change-password",
+	"openid-configuration": "/.well-known/openid-configuration",
+}
+
+// checkWellKnown applies the built-in assertions of a --well-known preset.
+func checkWellKnown(opts commandOpts, res *http.Response, body []byte, redirects int) (string, *reqError) {
+	fail := func(format string, args ...interface{}) (string, *reqError) {
+		return "", &reqError{
+			fmt.Sprintf("HTTP CRITICAL - %s: %s from host on port %d", opts.WellKnown, fmt.Sprintf(format, args...), opts.Port),
+			CRITICAL,
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	switch opts.WellKnown {
+	case "security.txt":
+		if res.StatusCode != http.StatusOK {
+			return fail("unexpected status %s", res.Status)
+		}
+		if mediaType != "text/plain" {
+			return fail("content type %q is not text/plain", mediaType)
+		}
+		fields := map[string]string{}
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), ":")
+			if !ok || strings.HasPrefix(key, "#") {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			if _, exists := fields[key]; !exists {
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+		if fields["contact"] == "" {
+			return fail("required field Contact missing")
+		}
+		if fields["expires"] == "" {
+			return fail("required field Expires missing")
+		}
+		expires, err := time.Parse(time.RFC3339, fields["expires"])
+		if err != nil {
+			return fail("invalid Expires %q", fields["expires"])
+		}
+		if time.Now().After(expires) {
+			return fail("expired at %s", expires.Format(time.RFC3339))
+		}
+		return fmt.Sprintf("security.txt valid until %s", expires.Format("2006-01-02")), nil
+
+	case "change-password":
+		switch {
+		case redirects > 0:
+		case res.StatusCode == http.StatusFound, res.StatusCode == http.StatusSeeOther, res.StatusCode == http.StatusTemporaryRedirect:
+			if res.Header.Get("Location") == "" {
+				return fail("redirect without Location header")
+			}
+		default:
+			return fail("expected redirect but got %s", res.Status)
+		}
+		return "change-password redirects", nil
+
+	case "openid-configuration":
+		if res.StatusCode != http.StatusOK {
+			return fail("unexpected status %s", res.Status)
+		}
+		if mediaType != "application/json" {
+			return fail("content type %q is not application/json", mediaType)
+		}
+		doc, err := parseJSONBody(body)
+		if err != nil {
+			return fail("invalid JSON: %v", err)
+		}
+		config, ok := doc.(map[string]interface{})
+		if !ok {
+			return fail("document is not a JSON object")
+		}
+		for _, field := range []string{"issuer", "authorization_endpoint", "jwks_uri", "response_types_supported", "subject_types_supported", "id_token_signing_alg_values_supported"} {
+			if _, ok := config[field]; !ok {
+				return fail("required field %s missing", field)
+			}
+		}
+		return fmt.Sprintf("openid-configuration of %v valid", config["issuer"]), nil
+	}
+	return "", nil
+}